@@ -0,0 +1,52 @@
+-- name: InsertAuditLog :exec
+INSERT INTO audit_log (
+    -- COLUMS --
+    created_at, --
+    actor, --
+    action, --
+    resource, --
+    record_ids, --
+    subject, --
+    client_ip, --
+    request_id, --
+    filter, --
+    records --
+)
+    VALUES (
+        -- VALUES --
+        $1, --
+        $2, --
+        $3, --
+        $4, --
+        $5, --
+        $6, --
+        $7, --
+        $8, --
+        $9, --
+        $10 --
+);
+
+-- name: SelectAuditLog :many
+-- Entries matching the filters that are set, newest first. The cursor is the
+-- id of the last entry of the previous page.
+SELECT
+    *
+FROM
+    audit_log
+WHERE (sqlc.narg ('created_after')::timestamp IS NULL
+    OR created_at >= sqlc.narg ('created_after'))
+AND (sqlc.narg ('created_before')::timestamp IS NULL
+    OR created_at < sqlc.narg ('created_before'))
+AND (sqlc.narg ('actor')::text IS NULL
+    OR actor = sqlc.narg ('actor'))
+AND (sqlc.narg ('action')::text IS NULL
+    OR action = sqlc.narg ('action'))
+AND (sqlc.narg ('resource')::text IS NULL
+    OR resource = sqlc.narg ('resource'))
+AND (sqlc.narg ('record_id')::int IS NULL
+    OR record_ids @> ARRAY[sqlc.narg ('record_id')::int])
+AND (sqlc.narg ('cursor_id')::int IS NULL
+    OR id < sqlc.narg ('cursor_id'))
+ORDER BY
+    id DESC
+LIMIT sqlc.arg ('limit');
@@ -0,0 +1,7 @@
+-- name: UpdateMailBlindIndex :exec
+UPDATE
+    mails
+SET
+    blind_index = $2
+WHERE
+    id = $1;
@@ -0,0 +1,9 @@
+-- name: UseCaptchaChallenge :execrows
+INSERT INTO captcha_challenges (id, expires_at)
+    VALUES ($1, $2)
+ON CONFLICT (id)
+    DO NOTHING;
+
+-- name: DeleteExpiredCaptchaChallenges :exec
+DELETE FROM captcha_challenges
+WHERE expires_at < $1;
@@ -0,0 +1,42 @@
+-- name: SelectClassifierTokens :many
+SELECT
+    *
+FROM
+    classifier_tokens
+WHERE
+    token = ANY (sqlc.arg ('tokens')::text[]);
+
+-- name: SelectClassifierDocuments :many
+SELECT
+    *
+FROM
+    classifier_documents;
+
+-- name: UpdateClassifierTokens :exec
+INSERT INTO classifier_tokens (token, spam, ham)
+SELECT
+    unnest(sqlc.arg ('tokens')::text[]),
+    GREATEST (0, sqlc.arg ('spam')::int),
+    GREATEST (0, sqlc.arg ('ham')::int)
+ON CONFLICT (token)
+    DO UPDATE SET
+        spam = GREATEST (0, classifier_tokens.spam + sqlc.arg ('spam')::int),
+        ham = GREATEST (0, classifier_tokens.ham + sqlc.arg ('ham')::int);
+
+-- name: UpdateClassifierDocuments :exec
+INSERT INTO classifier_documents (label, documents)
+    VALUES (sqlc.arg ('label'), GREATEST (0, sqlc.arg ('documents')::int))
+ON CONFLICT (label)
+    DO UPDATE SET
+        documents = GREATEST (0, classifier_documents.documents + sqlc.arg ('documents')::int);
+
+-- name: SetMailClassification :execrows
+-- Only updates the mail when it is still classified as previous_classified_as,
+-- returns 0 when someone else classified it in the meantime.
+UPDATE
+    mails
+SET
+    classified_as = $2
+WHERE
+    id = $1
+    AND classified_as IS NOT DISTINCT FROM sqlc.narg ('previous_classified_as');
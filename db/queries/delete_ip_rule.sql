@@ -0,0 +1,3 @@
+-- name: DeleteIPRule :execrows
+DELETE FROM ip_rules
+WHERE id = $1;
@@ -0,0 +1,19 @@
+-- name: InsertIPRule :one
+INSERT INTO ip_rules (
+    -- COLUMS --
+    created_at, --
+    cidr, --
+    action, --
+    comment, --
+    expires_at --
+)
+    VALUES (
+        -- VALUES --
+        $1, --
+        $2, --
+        $3, --
+        $4, --
+        $5 --
+)
+RETURNING
+    *;
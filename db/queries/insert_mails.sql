@@ -8,7 +8,22 @@ INSERT INTO mails (
     recipients, --
     subject, --
     content, --
-    error --
+    error, --
+    form_id, --
+    spam, --
+    spam_score, --
+    spam_reasons, --
+    fingerprint, --
+    idempotency_key, --
+    captcha_score, --
+    submission_id, --
+    client_ip, --
+    user_agent, --
+    origin, --
+    language, --
+    country, --
+    request_id, --
+    blind_index --
 )
     VALUES (
         -- VALUES --
@@ -19,7 +34,48 @@ INSERT INTO mails (
         $5, --
         $6, --
         $7, --
-        $8 --
+        $8, --
+        $9, --
+        $10, --
+        $11, --
+        $12, --
+        $13, --
+        $14, --
+        $15, --
+        $16, --
+        $17, --
+        $18, --
+        $19, --
+        $20, --
+        $21, --
+        $22, --
+        $23 --
 )
 RETURNING
     id;
+
+-- name: SetMailResult :exec
+-- Records the outcome of sending a mail that was stored before it was sent.
+UPDATE
+    mails
+SET
+    success = $2,
+    error = $3
+WHERE
+    id = $1;
+
+-- name: AbandonMail :execrows
+-- Gives up on a mail that is still pending, it was left behind by a crash
+-- between storing and sending it. Frees its idempotency key and fingerprint so
+-- a retry can be sent.
+UPDATE
+    mails
+SET
+    error = $2,
+    idempotency_key = NULL,
+    fingerprint = NULL
+WHERE
+    id = $1
+    AND NOT success
+    AND NOT spam
+    AND COALESCE(error, '') = '';
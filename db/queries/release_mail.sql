@@ -0,0 +1,14 @@
+-- name: ReleaseMail :execrows
+-- Takes the mail out of the spam before it is sent, returns 0 when it is not
+-- spam (anymore) so concurrent releases only send it once. The outcome of the
+-- send is recorded with SetMailResult.
+UPDATE
+    mails
+SET
+    spam = FALSE,
+    released_at = $2,
+    success = FALSE,
+    error = NULL
+WHERE
+    id = $1
+    AND spam;
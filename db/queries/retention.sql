@@ -0,0 +1,118 @@
+-- name: CountExpiredMails :one
+-- Mails and submissions expire when they are older than the cutoff of their
+-- form, forms without a cutoff use the default cutoff.
+SELECT
+    count(*)
+FROM
+    mails
+WHERE
+    created_at < COALESCE((
+        SELECT
+            r.cutoff
+        FROM unnest(sqlc.arg ('form_ids')::uuid[], sqlc.arg ('cutoffs')::timestamp[]) AS r (form_id, cutoff)
+        WHERE
+            r.form_id = mails.form_id), sqlc.arg ('default_cutoff')::timestamp);
+
+-- name: DeleteExpiredMails :one
+-- Deletes the expired mails and, when keep_statistics is set, adds them to the
+-- statistics in the same statement. Returns the amount of deleted mails.
+WITH deleted AS (
+    DELETE FROM mails
+    WHERE created_at < COALESCE((
+            SELECT
+                r.cutoff
+            FROM unnest(sqlc.arg ('form_ids')::uuid[], sqlc.arg ('cutoffs')::timestamp[]) AS r (form_id, cutoff)
+            WHERE
+                r.form_id = mails.form_id), sqlc.arg ('default_cutoff')::timestamp)
+    RETURNING
+        created_at,
+        form_id,
+        spam,
+        success
+),
+statistics AS (
+INSERT INTO mail_statistics (day, form_id, spam, success, mails)
+    SELECT
+        created_at::date,
+        COALESCE(form_id, '00000000-0000-0000-0000-000000000000'),
+        spam,
+        success,
+        count(*)
+    FROM
+        deleted
+    WHERE
+        sqlc.arg ('keep_statistics')::bool
+    GROUP BY
+        1,
+        2,
+        3,
+        4
+    ON CONFLICT (day,
+        form_id,
+        spam,
+        success)
+        DO UPDATE SET
+            mails = mail_statistics.mails + EXCLUDED.mails)
+SELECT
+    count(*)
+FROM
+    deleted;
+
+-- name: CountExpiredSubmissions :one
+SELECT
+    count(*)
+FROM
+    submissions
+WHERE
+    created_at < COALESCE((
+        SELECT
+            r.cutoff
+        FROM unnest(sqlc.arg ('form_ids')::uuid[], sqlc.arg ('cutoffs')::timestamp[]) AS r (form_id, cutoff)
+        WHERE
+            r.form_id = submissions.form_id), sqlc.arg ('default_cutoff')::timestamp);
+
+-- name: DeleteExpiredSubmissions :execrows
+DELETE FROM submissions
+WHERE created_at < COALESCE((
+        SELECT
+            r.cutoff
+        FROM unnest(sqlc.arg ('form_ids')::uuid[], sqlc.arg ('cutoffs')::timestamp[]) AS r (form_id, cutoff)
+        WHERE
+            r.form_id = submissions.form_id), sqlc.arg ('default_cutoff')::timestamp);
+
+-- name: InsertPurgeRun :one
+INSERT INTO purge_runs (
+    -- COLUMS --
+    started_at, --
+    finished_at, --
+    dry_run, --
+    mails, --
+    submissions, --
+    error --
+)
+    VALUES (
+        -- VALUES --
+        $1, --
+        $2, --
+        $3, --
+        $4, --
+        $5, --
+        $6 --
+)
+RETURNING
+    *;
+
+-- name: SelectPurgeRuns :many
+SELECT
+    *
+FROM
+    purge_runs
+ORDER BY
+    id DESC
+LIMIT sqlc.arg ('limit');
+
+-- name: TryAdvisoryXactLock :one
+-- Takes the advisory lock till the end of the transaction, returns false when
+-- another transaction holds it.
+SELECT
+    pg_try_advisory_xact_lock(sqlc.arg ('key')::bigint)::boolean AS locked;
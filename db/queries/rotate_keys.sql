@@ -0,0 +1,49 @@
+-- name: SelectMailsAfterID :many
+SELECT
+    *
+FROM
+    mails
+WHERE
+    id > sqlc.arg ('after_id')
+ORDER BY
+    id
+LIMIT sqlc.arg ('limit');
+
+-- name: UpdateMailCiphertexts :execrows
+-- Only updates the mail when its content is still the ciphertext that was
+-- re-encrypted, returns 0 when the mail changed in the meantime, eg. was erased.
+UPDATE
+    mails
+SET
+    mail_from = $2,
+    recipients = $3,
+    subject = $4,
+    content = $5,
+    client_ip = $6,
+    user_agent = $7,
+    origin = $8
+WHERE
+    id = $1
+    AND content = sqlc.arg ('previous_content');
+
+-- name: SelectSubmissionsAfterID :many
+SELECT
+    *
+FROM
+    submissions
+WHERE
+    id > sqlc.arg ('after_id')
+ORDER BY
+    id
+LIMIT sqlc.arg ('limit');
+
+-- name: UpdateSubmissionFields :execrows
+-- Only updates the submission when its fields are still the ciphertext that was
+-- re-encrypted, returns 0 when the submission changed in the meantime.
+UPDATE
+    submissions
+SET
+    fields = $2
+WHERE
+    id = $1
+    AND fields = sqlc.arg ('previous_fields');
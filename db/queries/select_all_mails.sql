@@ -3,23 +3,64 @@ SELECT
     *
 FROM
     mails
+WHERE
+    spam = sqlc.arg ('spam')
 ORDER BY
     created_at DESC
-LIMIT $1 offset $2;
+LIMIT sqlc.arg ('limit') offset sqlc.arg ('offset');
 
 -- name: CountAllMails :one
 SELECT
     count(*)
 FROM
-    mails;
--- name: FilterMailsOnCreatedAt :many
+    mails
+WHERE
+    spam = sqlc.arg ('spam');
+
+-- name: SearchMails :many
+-- Mails matching the filters that are set, newest first. The cursor is the
+-- created_at and id of the last mail of the previous page.
 SELECT
     *
 FROM
     mails
-WHERE
-    created_at > sqlc.narg ('created_at_lt')
-    AND created_at < sqlc.narg ('created_at_gt')
+WHERE (sqlc.narg ('created_after')::timestamp IS NULL
+    OR created_at >= sqlc.narg ('created_after'))
+AND (sqlc.narg ('created_before')::timestamp IS NULL
+    OR created_at < sqlc.narg ('created_before'))
+AND (sqlc.narg ('form_id')::uuid IS NULL
+    OR form_id = sqlc.narg ('form_id'))
+AND (sqlc.narg ('mail_provider')::text IS NULL
+    OR mail_provider = sqlc.narg ('mail_provider'))
+AND (sqlc.narg ('success')::bool IS NULL
+    OR success = sqlc.narg ('success'))
+AND (sqlc.narg ('spam')::bool IS NULL
+    OR spam = sqlc.narg ('spam'))
+AND (sqlc.narg ('blind_index')::text IS NULL
+    OR blind_index @> ARRAY[sqlc.narg ('blind_index')::text])
+AND (sqlc.narg ('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg ('cursor_created_at'), sqlc.narg ('cursor_id')::int))
 ORDER BY
-    created_at DESC
-LIMIT $1;
+    created_at DESC,
+    id DESC
+LIMIT sqlc.arg ('limit') offset sqlc.arg ('offset');
+
+-- name: CountSearchMails :one
+SELECT
+    count(*)
+FROM
+    mails
+WHERE (sqlc.narg ('created_after')::timestamp IS NULL
+    OR created_at >= sqlc.narg ('created_after'))
+AND (sqlc.narg ('created_before')::timestamp IS NULL
+    OR created_at < sqlc.narg ('created_before'))
+AND (sqlc.narg ('form_id')::uuid IS NULL
+    OR form_id = sqlc.narg ('form_id'))
+AND (sqlc.narg ('mail_provider')::text IS NULL
+    OR mail_provider = sqlc.narg ('mail_provider'))
+AND (sqlc.narg ('success')::bool IS NULL
+    OR success = sqlc.narg ('success'))
+AND (sqlc.narg ('spam')::bool IS NULL
+    OR spam = sqlc.narg ('spam'))
+AND (sqlc.narg ('blind_index')::text IS NULL
+    OR blind_index @> ARRAY[sqlc.narg ('blind_index')::text]);
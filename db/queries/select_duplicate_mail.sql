@@ -0,0 +1,33 @@
+-- name: SelectDuplicateMail :one
+SELECT
+    *
+FROM
+    mails
+WHERE
+    form_id = $1
+    AND fingerprint = $2
+    AND created_at > $3
+ORDER BY
+    created_at DESC
+LIMIT 1;
+
+-- name: SelectMailByIdempotencyKey :one
+SELECT
+    *
+FROM
+    mails
+WHERE
+    form_id = $1
+    AND idempotency_key = $2
+ORDER BY
+    created_at DESC
+LIMIT 1;
+
+-- name: IncrementMailDuplicates :exec
+UPDATE
+    mails
+SET
+    duplicates = duplicates + 1,
+    last_duplicate_at = $2
+WHERE
+    id = $1;
@@ -0,0 +1,10 @@
+-- name: SelectActiveIPRules :many
+SELECT
+    *
+FROM
+    ip_rules
+WHERE
+    expires_at IS NULL
+    OR expires_at > sqlc.arg ('now')
+ORDER BY
+    id;
@@ -0,0 +1,55 @@
+-- name: CountMailsByBucket :many
+-- Mails per bucket, form and provider. The bucket is a date_trunc field, eg.
+-- hour, day, week or month.
+SELECT
+    date_trunc(sqlc.arg ('bucket')::text, created_at)::timestamp AS bucket,
+    form_id,
+    mail_provider,
+    count(*) AS mails,
+    count(*) FILTER (WHERE spam) AS spam,
+    count(*) FILTER (WHERE success) AS success
+FROM
+    mails
+WHERE
+    created_at >= sqlc.arg ('created_after')::timestamp
+    AND created_at < sqlc.arg ('created_before')::timestamp
+GROUP BY
+    1,
+    2,
+    3
+ORDER BY
+    1,
+    2,
+    3;
+
+-- name: CountPurgedMailsByBucket :many
+-- The statistics kept of the purged mails per bucket and form, they are per
+-- day so an hour bucket is the start of the day.
+SELECT
+    date_trunc(sqlc.arg ('bucket')::text, day::timestamp)::timestamp AS bucket,
+    form_id,
+    sum(mails)::bigint AS mails,
+    COALESCE(sum(mails) FILTER (WHERE spam), 0)::bigint AS spam,
+    COALESCE(sum(mails) FILTER (WHERE success), 0)::bigint AS success
+FROM
+    mail_statistics
+WHERE
+    day >= sqlc.arg ('created_after')::timestamp
+    AND day < sqlc.arg ('created_before')::timestamp
+GROUP BY
+    1,
+    2
+ORDER BY
+    1,
+    2;
+
+-- name: SelectMailOrigins :many
+-- The encrypted origins of the mails, they are counted after decryption.
+SELECT
+    origin
+FROM
+    mails
+WHERE
+    created_at >= sqlc.arg ('created_after')::timestamp
+    AND created_at < sqlc.arg ('created_before')::timestamp
+    AND origin IS NOT NULL;
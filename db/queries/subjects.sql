@@ -0,0 +1,58 @@
+-- name: SelectSubjectMails :many
+SELECT
+    *
+FROM
+    mails
+WHERE
+    blind_index @> ARRAY[sqlc.arg ('hash')::text]
+ORDER BY
+    id;
+
+-- name: DeleteMailsByID :execrows
+DELETE FROM mails
+WHERE id = ANY (sqlc.arg ('ids')::int[]);
+
+-- name: ShredMailsByID :execrows
+-- Wipes everything personal of the mails, the rows stay for the statistics.
+UPDATE
+    mails
+SET
+    mail_from = '',
+    recipients = '{}',
+    subject = '',
+    content = '',
+    client_ip = NULL,
+    user_agent = NULL,
+    origin = NULL,
+    fingerprint = NULL,
+    idempotency_key = NULL,
+    submission_id = NULL,
+    spam_reasons = '{}',
+    language = NULL,
+    country = NULL,
+    request_id = NULL,
+    blind_index = '{}'
+WHERE
+    id = ANY (sqlc.arg ('ids')::int[]);
+
+-- name: DeleteSubmissionsByID :execrows
+DELETE FROM submissions
+WHERE id = ANY (sqlc.arg ('ids')::int[]);
+
+-- name: InsertSubjectRequest :exec
+INSERT INTO subject_requests (
+    -- COLUMS --
+    created_at, --
+    action, --
+    subject, --
+    mails, --
+    submissions --
+)
+    VALUES (
+        -- VALUES --
+        $1, --
+        $2, --
+        $3, --
+        $4, --
+        $5 --
+);
@@ -0,0 +1,41 @@
+-- name: InsertSubmission :one
+INSERT INTO submissions (
+    -- COLUMS --
+    created_at, --
+    form_id, --
+    fields --
+)
+    VALUES (
+        -- VALUES --
+        $1, --
+        $2, --
+        $3 --
+)
+RETURNING
+    id;
+
+-- name: SelectSubmissionByID :one
+SELECT
+    *
+FROM
+    submissions
+WHERE
+    id = $1;
+
+-- name: SelectMailIDsBySubmissionID :many
+SELECT
+    id
+FROM
+    mails
+WHERE
+    submission_id = $1
+ORDER BY
+    id;
+
+-- name: SelectSubmissionsByID :many
+SELECT
+    *
+FROM
+    submissions
+WHERE
+    id = ANY (sqlc.arg ('ids')::int[]);
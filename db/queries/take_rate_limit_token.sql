@@ -0,0 +1,34 @@
+-- name: TakeRateLimitToken :one
+INSERT INTO rate_limits (
+    -- COLUMS --
+    bucket, --
+    tokens, --
+    allowed, --
+    updated_at --
+)
+    VALUES (
+        -- VALUES --
+        sqlc.arg ('bucket'), --
+        sqlc.arg ('capacity')::double precision - 1, --
+        TRUE, --
+        sqlc.arg ('now') --
+)
+ON CONFLICT (bucket)
+    DO UPDATE SET
+        -- Refill the bucket for the time passed since the last request, then take a token if there is one.
+        tokens = CASE WHEN LEAST(sqlc.arg ('capacity')::double precision, rate_limits.tokens + GREATEST(0, EXTRACT(EPOCH FROM (sqlc.arg ('now')::timestamp - rate_limits.updated_at)))::double precision * sqlc.arg ('refill_per_second')::double precision) >= 1 THEN
+            LEAST(sqlc.arg ('capacity')::double precision, rate_limits.tokens + GREATEST(0, EXTRACT(EPOCH FROM (sqlc.arg ('now')::timestamp - rate_limits.updated_at)))::double precision * sqlc.arg ('refill_per_second')::double precision) - 1
+        ELSE
+            LEAST(sqlc.arg ('capacity')::double precision, rate_limits.tokens + GREATEST(0, EXTRACT(EPOCH FROM (sqlc.arg ('now')::timestamp - rate_limits.updated_at)))::double precision * sqlc.arg ('refill_per_second')::double precision)
+        END,
+        allowed = LEAST(sqlc.arg ('capacity')::double precision, rate_limits.tokens + GREATEST(0, EXTRACT(EPOCH FROM (sqlc.arg ('now')::timestamp - rate_limits.updated_at)))::double precision * sqlc.arg ('refill_per_second')::double precision) >= 1,
+        updated_at = sqlc.arg ('now')
+    RETURNING
+        tokens,
+        allowed;
+
+-- name: DeleteFullRateLimits :execrows
+-- A bucket that was not used for longer than it takes to refill is full, which
+-- is the same as having no bucket at all.
+DELETE FROM rate_limits
+WHERE updated_at < $1;
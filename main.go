@@ -2,12 +2,9 @@ package main
 
 import (
 	"context"
-	"net/url"
+	"strconv"
 
-	"github.com/amacneil/dbmate/v2/pkg/dbmate"
-	_ "github.com/amacneil/dbmate/v2/pkg/driver/postgres"
 	app "github.com/sevaho/goforms/src"
-	"github.com/sevaho/goforms/src/assets"
 	"github.com/sevaho/goforms/src/config"
 	"github.com/sevaho/goforms/src/pkg/logger"
 	"github.com/spf13/pflag"
@@ -16,12 +13,20 @@ import (
 func main() {
 	// parse arguments (flags)
 	var (
-		serve          = pflag.Bool("serve", false, "Serve the application.")
-		port           = pflag.IntP("port", "p", 3000, "Which port to run on.")
-		configfilepath = pflag.StringP("config", "c", "config.yaml", "Path to config file.")
-		migrate        = pflag.Bool("migrate", false, "Run migrations.")
-		newmigration   = pflag.Bool("new-migration", false, "New migrations.")
+		serve           = pflag.Bool("serve", false, "Serve the application.")
+		port            = pflag.IntP("port", "p", 3000, "Which port to run on.")
+		configfilepath  = pflag.StringP("config", "c", "config.yaml", "Path to config file.")
+		migrate         = pflag.Bool("migrate", false, "Run migrations.")
+		migrationstatus = pflag.Bool("migration-status", false, "List the applied and pending migrations.")
+		rollback        = pflag.Int("rollback", 0, "Roll back the last n migrations, 1 without n.")
+		newmigration    = pflag.String("new-migration", "", "Create a migration with the name in src/assets/migrations.")
+		rotatekeys      = pflag.Bool("rotate-keys", false, "Re-encrypt the stored data with SECRET_KEY.")
+		reindex         = pflag.Bool("reindex", false, "Recompute the blind index of the stored mails.")
+		purge           = pflag.Bool("purge", false, "Purge the mails that are older than their retention.")
+		dryrun          = pflag.Bool("dry-run", false, "Only report what --purge would delete.")
+		batchsize       = pflag.Int("batch-size", 500, "Rows per batch when rotating keys or reindexing.")
 	)
+	pflag.Lookup("rollback").NoOptDefVal = "1"
 	pflag.Parse()
 
 	if *serve {
@@ -35,35 +40,46 @@ func main() {
 		app.Run(ctx, *port, config)
 	} else if *migrate {
 		Migrate()
-	} else if *newmigration {
-		NewMigration()
-	} else {
-		logger.Logger.Warn().Msg("No flags given, exiting!")
-		pflag.PrintDefaults()
-	}
-}
-
-func NewMigration() {
-	config := config.New()
-
-	u, _ := url.Parse(config.DB_DSN)
-	db := dbmate.New(u)
-	db.NewMigration("RENAME_ME")
-}
+	} else if *migrationstatus {
+		if err := MigrationStatus(); err != nil {
+			logger.Logger.Fatal().Err(err).Msg("Failed to get the migration status")
+		}
+	} else if *rollback > 0 {
+		steps := *rollback
+		// --rollback 3 as well as --rollback=3
+		if pflag.NArg() > 0 {
+			if n, err := strconv.Atoi(pflag.Arg(0)); err == nil && n > 0 {
+				steps = n
+			}
+		}
 
-func Migrate() {
-	logger.Init(true, 1)
-	config := config.New()
+		if err := Rollback(steps); err != nil {
+			logger.Logger.Fatal().Err(err).Msg("Failed to roll back")
+		}
+	} else if *newmigration != "" {
+		if err := NewMigration(*newmigration); err != nil {
+			logger.Logger.Fatal().Err(err).Msg("Failed to create the migration")
+		}
+	} else if *rotatekeys {
+		if err := app.RotateKeys(context.Background(), *batchsize, config.New()); err != nil {
+			logger.Logger.Fatal().Err(err).Msg("Failed to rotate keys")
+		}
+	} else if *reindex {
+		config := config.New()
+		config.FORMS_CONFIG_FILE_PATH = *configfilepath
 
-	u, _ := url.Parse(config.DB_DSN)
-	db := dbmate.New(u)
-	db.FS = assets.Migrations
-	db.MigrationsDir = []string{"migrations"}
+		if err := app.Reindex(context.Background(), *batchsize, config); err != nil {
+			logger.Logger.Fatal().Err(err).Msg("Failed to reindex")
+		}
+	} else if *purge {
+		config := config.New()
+		config.FORMS_CONFIG_FILE_PATH = *configfilepath
 
-	logger.Logger.Info().Msg("Applying migrations...")
-	err := db.CreateAndMigrate()
-	if err != nil {
-		panic(err)
+		if err := app.Purge(context.Background(), *dryrun, config); err != nil {
+			logger.Logger.Fatal().Err(err).Msg("Failed to purge")
+		}
+	} else {
+		logger.Logger.Warn().Msg("No flags given, exiting!")
+		pflag.PrintDefaults()
 	}
-	logger.Logger.Info().Msg("Migrations applied!")
 }
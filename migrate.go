@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/amacneil/dbmate/v2/pkg/dbmate"
+	_ "github.com/amacneil/dbmate/v2/pkg/driver/postgres"
+	"github.com/sevaho/goforms/src/assets"
+	"github.com/sevaho/goforms/src/config"
+	"github.com/sevaho/goforms/src/db/memory"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+// The migrations are embedded from here, new ones are written to the source.
+const migrationsSource = "src/assets"
+
+// Same as the template of dbmate
+const migrationTemplate = "-- migrate:up\n\n\n-- migrate:down\n\n"
+
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// SQLite has its own migrations next to the ones of Postgres.
+func migrationsDir(u *url.URL) string {
+	if u.Scheme == "sqlite" || u.Scheme == "sqlite3" {
+		return "migrations/sqlite"
+	}
+	return "migrations"
+}
+
+// Opens dbmate on the embedded migrations of DB_DSN, nil for the in-memory
+// store as it has no migrations.
+func migrator() *dbmate.DB {
+	logger.Init(true, 1)
+	config := config.New()
+
+	if memory.IsDSN(config.DB_DSN) {
+		logger.Logger.Info().Msg("The in-memory store has no migrations")
+		return nil
+	}
+
+	u, _ := url.Parse(config.DB_DSN)
+	db := dbmate.New(u)
+	db.FS = assets.Migrations
+	db.MigrationsDir = []string{migrationsDir(u)}
+	return db
+}
+
+// Writes an empty migration for Postgres and one with the same version for
+// SQLite, every change to the schema needs both.
+func NewMigration(name string) error {
+	logger.Init(true, 1)
+
+	if !migrationName.MatchString(name) {
+		return errors.New("The name of a migration should only have lowercase letters, digits and underscores, eg. add_mails_tags")
+	}
+
+	version := time.Now().UTC().Format("20060102150405")
+	for _, dir := range []string{"migrations", "migrations/sqlite"} {
+		path := filepath.Join(migrationsSource, dir, version+"_"+name+".sql")
+
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return err
+		}
+		_, err = file.WriteString(migrationTemplate)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+
+		logger.Logger.Info().Msgf("Created %s", path)
+	}
+	return nil
+}
+
+func Migrate() {
+	db := migrator()
+	if db == nil {
+		return
+	}
+
+	logger.Logger.Info().Msg("Applying migrations...")
+	err := db.CreateAndMigrate()
+	if err != nil {
+		panic(err)
+	}
+	logger.Logger.Info().Msg("Migrations applied!")
+}
+
+// Lists the applied and pending migrations.
+func MigrationStatus() error {
+	db := migrator()
+	if db == nil {
+		return nil
+	}
+
+	_, err := db.Status(false)
+	return err
+}
+
+// Rolls back the last steps migrations, newest first.
+func Rollback(steps int) error {
+	db := migrator()
+	if db == nil {
+		return nil
+	}
+
+	for range steps {
+		if err := db.Rollback(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
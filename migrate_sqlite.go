@@ -0,0 +1,6 @@
+//go:build cgo
+
+package main
+
+// The SQLite driver of dbmate needs cgo, builds without it can only migrate Postgres.
+import _ "github.com/amacneil/dbmate/v2/pkg/driver/sqlite"
@@ -12,3 +12,4 @@ CREATE TABLE mails (
 );
 
 -- migrate:down
+DROP TABLE mails;
@@ -0,0 +1,10 @@
+-- migrate:up
+CREATE TABLE rate_limits (
+    bucket text PRIMARY KEY, -- form id and client ip
+    tokens double precision NOT NULL,
+    allowed bool NOT NULL, -- whether the last request took a token
+    updated_at timestamp NOT NULL
+);
+
+-- migrate:down
+DROP TABLE rate_limits;
@@ -0,0 +1,12 @@
+-- migrate:up
+CREATE TABLE ip_rules (
+    id int GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    created_at timestamp NOT NULL,
+    cidr varchar(64) NOT NULL, -- single ip address or cidr range
+    action varchar(16) NOT NULL, -- block or allow
+    comment text,
+    expires_at timestamp
+);
+
+-- migrate:down
+DROP TABLE ip_rules;
@@ -0,0 +1,22 @@
+-- migrate:up
+ALTER TABLE mails
+    ADD COLUMN form_id uuid,
+    ADD COLUMN spam bool NOT NULL DEFAULT FALSE,
+    ADD COLUMN spam_score double precision NOT NULL DEFAULT 0,
+    ADD COLUMN spam_reasons text[] NOT NULL DEFAULT '{}',
+    ADD COLUMN released_at timestamp;
+
+COMMENT ON COLUMN mails.spam IS 'Submission scored over the spam threshold and was not sent';
+COMMENT ON COLUMN mails.released_at IS 'When the submission was released from spam and sent after all';
+
+CREATE INDEX mails_spam_created_at_idx ON mails (spam, created_at DESC);
+
+-- migrate:down
+DROP INDEX mails_spam_created_at_idx;
+
+ALTER TABLE mails
+    DROP COLUMN form_id,
+    DROP COLUMN spam,
+    DROP COLUMN spam_score,
+    DROP COLUMN spam_reasons,
+    DROP COLUMN released_at;
@@ -0,0 +1,25 @@
+-- migrate:up
+-- Tokens are stored as keyed hashes so the model does not leak submission content
+CREATE TABLE classifier_tokens (
+    token text PRIMARY KEY,
+    spam int NOT NULL DEFAULT 0, -- amount of spam documents containing the token
+    ham int NOT NULL DEFAULT 0 -- amount of ham documents containing the token
+);
+
+CREATE TABLE classifier_documents (
+    label varchar(16) PRIMARY KEY, -- spam or ham
+    documents int NOT NULL DEFAULT 0
+);
+
+ALTER TABLE mails
+    ADD COLUMN classified_as varchar(16);
+
+COMMENT ON COLUMN mails.classified_as IS 'Label an admin gave the mail to train the spam classifier';
+
+-- migrate:down
+ALTER TABLE mails
+    DROP COLUMN classified_as;
+
+DROP TABLE classifier_documents;
+
+DROP TABLE classifier_tokens;
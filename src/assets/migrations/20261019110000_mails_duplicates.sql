@@ -0,0 +1,26 @@
+-- migrate:up
+ALTER TABLE mails
+    ADD COLUMN fingerprint text,
+    ADD COLUMN idempotency_key text,
+    ADD COLUMN duplicates int NOT NULL DEFAULT 0,
+    ADD COLUMN last_duplicate_at timestamp;
+
+COMMENT ON COLUMN mails.fingerprint IS 'Keyed hash of the normalized submitted fields';
+COMMENT ON COLUMN mails.duplicates IS 'Amount of identical submissions that were suppressed';
+
+CREATE INDEX mails_form_id_fingerprint_idx ON mails (form_id, fingerprint, created_at DESC);
+
+CREATE INDEX mails_form_id_idempotency_key_idx ON mails (form_id, idempotency_key)
+WHERE
+    idempotency_key IS NOT NULL;
+
+-- migrate:down
+DROP INDEX mails_form_id_idempotency_key_idx;
+
+DROP INDEX mails_form_id_fingerprint_idx;
+
+ALTER TABLE mails
+    DROP COLUMN fingerprint,
+    DROP COLUMN idempotency_key,
+    DROP COLUMN duplicates,
+    DROP COLUMN last_duplicate_at;
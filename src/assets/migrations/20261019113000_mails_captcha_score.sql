@@ -0,0 +1,9 @@
+-- migrate:up
+ALTER TABLE mails
+    ADD COLUMN captcha_score double precision;
+
+COMMENT ON COLUMN mails.captcha_score IS 'Score the captcha gave the submission, only reCAPTCHA v3 scores';
+
+-- migrate:down
+ALTER TABLE mails
+    DROP COLUMN captcha_score;
@@ -0,0 +1,12 @@
+-- migrate:up
+CREATE TABLE IF NOT EXISTS captcha_challenges (
+    id text PRIMARY KEY,
+    expires_at timestamp NOT NULL
+);
+
+COMMENT ON TABLE captcha_challenges IS 'Proof-of-work challenges that were used, to refuse replays';
+
+CREATE INDEX captcha_challenges_expires_at_idx ON captcha_challenges (expires_at);
+
+-- migrate:down
+DROP TABLE IF EXISTS captcha_challenges;
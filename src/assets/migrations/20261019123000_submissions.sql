@@ -0,0 +1,38 @@
+-- migrate:up
+CREATE TABLE IF NOT EXISTS submissions (
+    id int GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    created_at timestamp NOT NULL,
+    form_id uuid NOT NULL,
+    fields text NOT NULL
+);
+
+COMMENT ON COLUMN submissions.fields IS 'Encrypted JSON array of the submitted fields in submission order';
+
+CREATE INDEX submissions_form_id_created_at_idx ON submissions (form_id, created_at DESC);
+
+ALTER TABLE mails
+    ADD COLUMN submission_id int REFERENCES submissions (id) ON DELETE SET NULL;
+
+CREATE INDEX mails_submission_id_idx ON mails (submission_id);
+
+-- Mails used to be stored with the rendered submission in mail_from and the
+-- sender in content.
+UPDATE
+    mails
+SET
+    content = mail_from,
+    mail_from = content;
+
+-- migrate:down
+UPDATE
+    mails
+SET
+    content = mail_from,
+    mail_from = content;
+
+DROP INDEX mails_submission_id_idx;
+
+ALTER TABLE mails
+    DROP COLUMN submission_id;
+
+DROP TABLE IF EXISTS submissions;
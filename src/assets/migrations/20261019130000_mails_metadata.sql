@@ -0,0 +1,23 @@
+-- migrate:up
+ALTER TABLE mails
+    ADD COLUMN client_ip text,
+    ADD COLUMN user_agent text,
+    ADD COLUMN origin text,
+    ADD COLUMN language varchar(8),
+    ADD COLUMN country varchar(8),
+    ADD COLUMN request_id varchar(64);
+
+COMMENT ON COLUMN mails.client_ip IS 'Encrypted client IP address, truncated or hashed depending on the form';
+
+COMMENT ON COLUMN mails.user_agent IS 'Encrypted user agent of the client';
+
+COMMENT ON COLUMN mails.origin IS 'Encrypted origin or referer of the submission';
+
+-- migrate:down
+ALTER TABLE mails
+    DROP COLUMN client_ip,
+    DROP COLUMN user_agent,
+    DROP COLUMN origin,
+    DROP COLUMN language,
+    DROP COLUMN country,
+    DROP COLUMN request_id;
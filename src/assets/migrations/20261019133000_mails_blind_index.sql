@@ -0,0 +1,13 @@
+-- migrate:up
+ALTER TABLE mails
+    ADD COLUMN blind_index text[] NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN mails.blind_index IS 'Keyed hashes of the normalized email addresses and searchable fields';
+
+CREATE INDEX mails_blind_index_idx ON mails USING GIN (blind_index);
+
+-- migrate:down
+DROP INDEX mails_blind_index_idx;
+
+ALTER TABLE mails
+    DROP COLUMN blind_index;
@@ -0,0 +1,32 @@
+-- migrate:up
+CREATE TABLE purge_runs (
+    id int GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    started_at timestamp NOT NULL,
+    finished_at timestamp NOT NULL,
+    dry_run bool NOT NULL,
+    mails bigint NOT NULL, -- amount of mails that were (or would be) deleted
+    submissions bigint NOT NULL,
+    error text
+);
+
+COMMENT ON TABLE purge_runs IS 'Every run of the retention job, to show auditors old data is purged';
+
+CREATE TABLE mail_statistics (
+    day date NOT NULL,
+    form_id uuid NOT NULL, -- the nil UUID for mails without a form
+    spam bool NOT NULL,
+    success bool NOT NULL,
+    mails bigint NOT NULL,
+    PRIMARY KEY (day, form_id, spam, success)
+);
+
+COMMENT ON TABLE mail_statistics IS 'Anonymized counts of the mails that were purged';
+
+CREATE INDEX mails_created_at_idx ON mails (created_at);
+
+-- migrate:down
+DROP INDEX mails_created_at_idx;
+
+DROP TABLE mail_statistics;
+
+DROP TABLE purge_runs;
@@ -0,0 +1,16 @@
+-- migrate:up
+CREATE TABLE subject_requests (
+    id int GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    created_at timestamp NOT NULL,
+    action varchar(16) NOT NULL, -- export, delete or shred
+    subject text NOT NULL,
+    mails bigint NOT NULL,
+    submissions bigint NOT NULL
+);
+
+COMMENT ON TABLE subject_requests IS 'Exports and erasures of the data of a data subject';
+
+COMMENT ON COLUMN subject_requests.subject IS 'Keyed hash of the email address of the data subject';
+
+-- migrate:down
+DROP TABLE subject_requests;
@@ -0,0 +1,10 @@
+-- migrate:up
+-- Mails are paged by (created_at, id), newest first
+CREATE INDEX mails_created_at_id_idx ON mails (created_at DESC, id DESC);
+
+DROP INDEX mails_created_at_idx;
+
+-- migrate:down
+CREATE INDEX mails_created_at_idx ON mails (created_at);
+
+DROP INDEX mails_created_at_id_idx;
@@ -0,0 +1,41 @@
+-- migrate:up
+CREATE TABLE audit_log (
+    id int GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    created_at timestamp NOT NULL,
+    actor text NOT NULL,
+    action varchar(16) NOT NULL, -- view, export, resend, delete or shred
+    resource varchar(16) NOT NULL, -- mail or submission
+    record_ids int[] NOT NULL DEFAULT '{}',
+    subject text,
+    client_ip text,
+    request_id text
+);
+
+COMMENT ON TABLE audit_log IS 'Who viewed, exported, resent or deleted which records, rows are never changed';
+
+COMMENT ON COLUMN audit_log.actor IS 'ID of the API key that was used';
+
+COMMENT ON COLUMN audit_log.subject IS 'Keyed hash of the email address of a data subject request';
+
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+CREATE FUNCTION audit_log_append_only ()
+    RETURNS TRIGGER
+    AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$
+LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION audit_log_append_only ();
+
+-- migrate:down
+DROP TRIGGER audit_log_append_only ON audit_log;
+
+DROP FUNCTION audit_log_append_only ();
+
+DROP TABLE audit_log;
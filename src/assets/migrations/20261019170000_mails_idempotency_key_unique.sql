@@ -0,0 +1,31 @@
+-- migrate:up
+-- Concurrent retries could store the same key twice, the first mail keeps it
+UPDATE
+    mails
+SET
+    idempotency_key = NULL
+WHERE
+    idempotency_key IS NOT NULL
+    AND id NOT IN (
+        SELECT
+            min(id)
+        FROM
+            mails
+        WHERE
+            idempotency_key IS NOT NULL
+        GROUP BY
+            form_id,
+            idempotency_key);
+
+DROP INDEX mails_form_id_idempotency_key_idx;
+
+ALTER TABLE mails
+    ADD CONSTRAINT mails_form_id_idempotency_key_key UNIQUE (form_id, idempotency_key);
+
+-- migrate:down
+ALTER TABLE mails
+    DROP CONSTRAINT mails_form_id_idempotency_key_key;
+
+CREATE INDEX mails_form_id_idempotency_key_idx ON mails (form_id, idempotency_key)
+WHERE
+    idempotency_key IS NOT NULL;
@@ -0,0 +1,13 @@
+-- migrate:up
+ALTER TABLE audit_log
+    ADD COLUMN filter text,
+    ADD COLUMN records int;
+
+COMMENT ON COLUMN audit_log.filter IS 'Query of an export, without the email address and field value it searched for';
+
+COMMENT ON COLUMN audit_log.records IS 'Amount of records an export matched';
+
+-- migrate:down
+ALTER TABLE audit_log
+    DROP COLUMN filter,
+    DROP COLUMN records;
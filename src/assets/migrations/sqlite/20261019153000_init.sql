@@ -0,0 +1,137 @@
+-- migrate:up
+-- SQLite has no arrays, they are stored as JSON. Timestamps are stored as text
+-- in UTC so they sort in time order.
+CREATE TABLE submissions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at timestamp NOT NULL,
+    form_id text NOT NULL,
+    fields text NOT NULL -- encrypted JSON array of the submitted fields in submission order
+);
+
+CREATE INDEX submissions_form_id_created_at_idx ON submissions (form_id, created_at DESC);
+
+CREATE TABLE mails (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at timestamp NOT NULL,
+    mail_provider varchar(256) NOT NULL,
+    success boolean NOT NULL,
+    mail_from text NOT NULL, -- encrypted
+    recipients text NOT NULL DEFAULT '[]', -- JSON array of encrypted recipients
+    subject text NOT NULL, -- encrypted
+    content text NOT NULL, -- encrypted
+    error text,
+    form_id text,
+    spam boolean NOT NULL DEFAULT FALSE,
+    spam_score double precision NOT NULL DEFAULT 0,
+    spam_reasons text NOT NULL DEFAULT '[]',
+    released_at timestamp,
+    classified_as varchar(16),
+    fingerprint text,
+    idempotency_key text,
+    duplicates integer NOT NULL DEFAULT 0,
+    last_duplicate_at timestamp,
+    captcha_score double precision,
+    submission_id integer REFERENCES submissions (id) ON DELETE SET NULL,
+    client_ip text, -- encrypted
+    user_agent text, -- encrypted
+    origin text, -- encrypted
+    language varchar(8),
+    country varchar(8),
+    request_id varchar(64),
+    blind_index text NOT NULL DEFAULT '[]' -- JSON array of keyed hashes
+);
+
+CREATE INDEX mails_spam_created_at_idx ON mails (spam, created_at DESC);
+
+CREATE INDEX mails_created_at_id_idx ON mails (created_at DESC, id DESC);
+
+CREATE INDEX mails_form_id_fingerprint_idx ON mails (form_id, fingerprint, created_at DESC);
+
+CREATE INDEX mails_form_id_idempotency_key_idx ON mails (form_id, idempotency_key)
+WHERE
+    idempotency_key IS NOT NULL;
+
+CREATE INDEX mails_submission_id_idx ON mails (submission_id);
+
+CREATE TABLE rate_limits (
+    bucket text PRIMARY KEY, -- form id and client ip
+    tokens double precision NOT NULL,
+    allowed boolean NOT NULL, -- whether the last request took a token
+    updated_at timestamp NOT NULL
+);
+
+CREATE TABLE ip_rules (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at timestamp NOT NULL,
+    cidr varchar(64) NOT NULL, -- single ip address or cidr range
+    action varchar(16) NOT NULL, -- block or allow
+    comment text,
+    expires_at timestamp
+);
+
+CREATE TABLE classifier_tokens (
+    token text PRIMARY KEY,
+    spam integer NOT NULL DEFAULT 0,
+    ham integer NOT NULL DEFAULT 0
+);
+
+CREATE TABLE classifier_documents (
+    label varchar(16) PRIMARY KEY,
+    documents integer NOT NULL DEFAULT 0
+);
+
+CREATE TABLE captcha_challenges (
+    id text PRIMARY KEY,
+    expires_at timestamp NOT NULL
+);
+
+CREATE INDEX captcha_challenges_expires_at_idx ON captcha_challenges (expires_at);
+
+CREATE TABLE purge_runs (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    started_at timestamp NOT NULL,
+    finished_at timestamp NOT NULL,
+    dry_run boolean NOT NULL,
+    mails bigint NOT NULL,
+    submissions bigint NOT NULL,
+    error text
+);
+
+CREATE TABLE mail_statistics (
+    day date NOT NULL,
+    form_id text NOT NULL, -- the nil UUID for mails without a form
+    spam boolean NOT NULL,
+    success boolean NOT NULL,
+    mails bigint NOT NULL,
+    PRIMARY KEY (day, form_id, spam, success)
+);
+
+CREATE TABLE subject_requests (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at timestamp NOT NULL,
+    action varchar(16) NOT NULL, -- export, delete or shred
+    subject text NOT NULL, -- keyed hash of the email address
+    mails bigint NOT NULL,
+    submissions bigint NOT NULL
+);
+
+-- migrate:down
+DROP TABLE subject_requests;
+
+DROP TABLE mail_statistics;
+
+DROP TABLE purge_runs;
+
+DROP TABLE captcha_challenges;
+
+DROP TABLE classifier_documents;
+
+DROP TABLE classifier_tokens;
+
+DROP TABLE ip_rules;
+
+DROP TABLE rate_limits;
+
+DROP TABLE mails;
+
+DROP TABLE submissions;
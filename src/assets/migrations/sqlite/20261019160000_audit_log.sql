@@ -0,0 +1,29 @@
+-- migrate:up
+CREATE TABLE audit_log (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at timestamp NOT NULL,
+    actor text NOT NULL, -- ID of the API key that was used
+    action varchar(16) NOT NULL, -- view, export, resend, delete or shred
+    resource varchar(16) NOT NULL, -- mail or submission
+    record_ids text NOT NULL DEFAULT '[]',
+    subject text, -- keyed hash of the email address of a data subject request
+    client_ip text,
+    request_id text
+);
+
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+CREATE TRIGGER audit_log_no_update
+    BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER audit_log_no_delete
+    BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+-- migrate:down
+DROP TABLE audit_log;
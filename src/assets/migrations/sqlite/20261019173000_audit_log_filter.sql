@@ -0,0 +1,11 @@
+-- migrate:up
+-- Query of an export, without the email address and field value it searched for
+ALTER TABLE audit_log ADD COLUMN filter text;
+
+-- Amount of records an export matched
+ALTER TABLE audit_log ADD COLUMN records integer;
+
+-- migrate:down
+ALTER TABLE audit_log DROP COLUMN records;
+
+ALTER TABLE audit_log DROP COLUMN filter;
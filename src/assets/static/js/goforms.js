@@ -0,0 +1,62 @@
+// Submits goforms forms in the background and shows the result inline.
+(function () {
+    function bind(form) {
+        if (form.dataset.goformsBound) {
+            return;
+        }
+        form.dataset.goformsBound = "true";
+
+        var message = form.querySelector("[data-goforms-message]");
+        var button = form.querySelector("[type=submit]");
+
+        function show(text, isError) {
+            if (!message) {
+                return;
+            }
+            message.textContent = text;
+            message.classList.toggle("text-error", isError);
+        }
+
+        form.addEventListener("submit", function (event) {
+            event.preventDefault();
+            if (button) {
+                button.disabled = true;
+            }
+            show("", false);
+
+            fetch(form.action, {
+                method: "POST",
+                body: new FormData(form),
+                headers: { Accept: "application/json" },
+            })
+                .then(function (response) {
+                    return response.json().catch(function () {
+                        return { success: false, error: response.statusText };
+                    });
+                })
+                .then(function (result) {
+                    if (!result.success) {
+                        throw new Error(result.error);
+                    }
+                    form.reset();
+                    show(form.dataset.success, false);
+                })
+                .catch(function (error) {
+                    show(error.message, true);
+                    if (window.grecaptcha) {
+                        window.grecaptcha.reset();
+                    }
+                })
+                .finally(function () {
+                    if (window.goformsPow) {
+                        window.goformsPow.reset(form);
+                    }
+                    if (button) {
+                        button.disabled = false;
+                    }
+                });
+        });
+    }
+
+    document.querySelectorAll("form[data-goforms]").forEach(bind);
+})();
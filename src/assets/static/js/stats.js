@@ -0,0 +1,199 @@
+// Draws the charts of the admin stats page from /api/stats.
+(function () {
+    var root = document.querySelector("[data-stats]");
+    if (!root) {
+        return;
+    }
+
+    var NIL_UUID = "00000000-0000-0000-0000-000000000000";
+
+    function element(tag, className, text) {
+        var el = document.createElement(tag);
+        if (className) {
+            el.className = className;
+        }
+        if (text !== undefined) {
+            el.textContent = text;
+        }
+        return el;
+    }
+
+    function percent(ratio) {
+        return Math.round(ratio * 1000) / 10 + "%";
+    }
+
+    // Same buckets as date_trunc on the server, in UTC and weeks start on monday
+    function truncate(date, bucket) {
+        var d = new Date(date.getTime());
+        d.setUTCMinutes(0, 0, 0);
+        if (bucket === "hour") {
+            return d;
+        }
+        d.setUTCHours(0);
+        if (bucket === "week") {
+            d.setUTCDate(d.getUTCDate() - ((d.getUTCDay() + 6) % 7));
+        } else if (bucket === "month") {
+            d.setUTCDate(1);
+        }
+        return d;
+    }
+
+    function next(date, bucket) {
+        var d = new Date(date.getTime());
+        if (bucket === "hour") {
+            d.setUTCHours(d.getUTCHours() + 1);
+        } else if (bucket === "week") {
+            d.setUTCDate(d.getUTCDate() + 7);
+        } else if (bucket === "month") {
+            d.setUTCMonth(d.getUTCMonth() + 1);
+        } else {
+            d.setUTCDate(d.getUTCDate() + 1);
+        }
+        return d;
+    }
+
+    function label(date, bucket) {
+        var iso = date.toISOString();
+        return bucket === "hour" ? iso.slice(0, 13).replace("T", " ") + ":00" : iso.slice(0, 10);
+    }
+
+    // A stacked bar per bucket with a color per form, empty buckets included
+    function drawForms(stats) {
+        var chart = root.querySelector("[data-stats-forms]");
+        var legend = root.querySelector("[data-stats-legend]");
+        chart.replaceChildren();
+        legend.replaceChildren();
+
+        var forms = [];
+        var buckets = {};
+        stats.Forms.forEach(function (row) {
+            if (forms.indexOf(row.FormID) === -1) {
+                forms.push(row.FormID);
+            }
+            var key = new Date(row.Bucket).toISOString();
+            buckets[key] = buckets[key] || [];
+            buckets[key].push(row);
+        });
+
+        var names = {};
+        stats.Forms.forEach(function (row) {
+            names[row.FormID] = row.Form || (row.FormID === NIL_UUID ? "No form" : row.FormID);
+        });
+
+        function color(formID) {
+            return "hsl(" + ((forms.indexOf(formID) * 137.5) % 360) + ", 60%, 55%)";
+        }
+
+        var max = 1;
+        Object.keys(buckets).forEach(function (key) {
+            var total = buckets[key].reduce(function (sum, row) {
+                return sum + row.Mails;
+            }, 0);
+            max = Math.max(max, total);
+        });
+
+        var end = new Date(stats.To);
+        for (var date = truncate(new Date(stats.From), stats.Bucket); date < end; date = next(date, stats.Bucket)) {
+            var rows = buckets[date.toISOString()] || [];
+            var column = element("div", "flex flex-col-reverse h-full min-w-2 flex-1");
+            var title = [label(date, stats.Bucket)];
+
+            rows.forEach(function (row) {
+                var segment = element("div");
+                segment.style.height = (row.Mails / max) * 100 + "%";
+                segment.style.background = color(row.FormID);
+                column.appendChild(segment);
+                title.push(names[row.FormID] + ": " + row.Mails + " (" + row.Spam + " spam, " + row.Success + " sent)");
+            });
+
+            column.title = title.join("\n");
+            chart.appendChild(column);
+        }
+
+        forms.forEach(function (formID) {
+            var item = element("span", "flex items-center gap-1");
+            var swatch = element("span", "inline-block w-3 h-3 rounded");
+            swatch.style.background = color(formID);
+            item.appendChild(swatch);
+            item.appendChild(document.createTextNode(names[formID]));
+            legend.appendChild(item);
+        });
+
+        if (!forms.length) {
+            legend.appendChild(element("span", "text-base-content/60", "No submissions in this range"));
+        }
+    }
+
+    function drawBars(container, items, className) {
+        container.replaceChildren();
+        items.forEach(function (item) {
+            var row = element("div");
+            var heading = element("div", "flex justify-between text-sm");
+            heading.appendChild(element("span", "truncate", item.label));
+            heading.appendChild(element("span", "text-base-content/60", item.value));
+            row.appendChild(heading);
+
+            var bar = element("progress", "progress " + className + " w-full");
+            bar.max = 100;
+            bar.value = item.ratio * 100;
+            row.appendChild(bar);
+            container.appendChild(row);
+        });
+        if (!items.length) {
+            container.appendChild(element("div", "text-sm text-base-content/60", "Nothing yet"));
+        }
+    }
+
+    function draw(stats) {
+        drawForms(stats);
+
+        drawBars(
+            root.querySelector("[data-stats-providers]"),
+            stats.Providers.map(function (provider) {
+                return {
+                    label: provider.Provider,
+                    value: percent(provider.SuccessRate) + " of " + provider.Mails,
+                    ratio: provider.SuccessRate,
+                };
+            }),
+            "progress-success",
+        );
+
+        var spam = root.querySelector("[data-stats-spam]");
+        spam.style.setProperty("--value", Math.round(stats.Spam.Ratio * 100));
+        spam.textContent = percent(stats.Spam.Ratio);
+        root.querySelector("[data-stats-spam-desc]").textContent = stats.Spam.Spam + " of " + stats.Spam.Mails + " mails";
+
+        var top = stats.Origins.length ? stats.Origins[0].Mails : 1;
+        drawBars(
+            root.querySelector("[data-stats-origins]"),
+            stats.Origins.map(function (origin) {
+                return { label: origin.Origin, value: String(origin.Mails), ratio: origin.Mails / top };
+            }),
+            "progress-primary",
+        );
+    }
+
+    var query = new URLSearchParams();
+    new URLSearchParams(window.location.search).forEach(function (value, key) {
+        if (value && ["from", "to", "bucket"].indexOf(key) !== -1) {
+            query.set(key, value);
+        }
+    });
+
+    fetch("/api/stats?" + query.toString(), {
+        headers: { Accept: "application/json", Authorization: "Bearer " + root.dataset.apiKey },
+    })
+        .then(function (response) {
+            return response.json().then(function (result) {
+                if (!response.ok) {
+                    throw new Error(result.Error || response.statusText);
+                }
+                return result;
+            });
+        })
+        .then(draw)
+        .catch(function (error) {
+            root.querySelector("[data-stats-error]").textContent = error.message;
+        });
+})();
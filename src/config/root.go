@@ -3,8 +3,10 @@ package config
 import (
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -23,6 +25,14 @@ type Config struct {
 	SECRET_KEY         string `required:"True"`
 	API_KEY            string
 
+	// Encryption key rotation, SECRET_KEY_ID is stored with everything SECRET_KEY
+	// encrypts. Keys that were rotated out can still decrypt, as id:key,id:key
+	SECRET_KEY_ID   string `default:"1"`
+	OLD_SECRET_KEYS map[string]string
+	// Keys the hashes that are looked up (fingerprints, classifier tokens, hashed
+	// IPs), defaults to SECRET_KEY. Required once OLD_SECRET_KEYS is set.
+	HASH_KEY string
+
 	// Telegram
 	TELEGRAM_BOT_API_KEY string `required:"True"`
 	TELEGRAM_BOT_CHAT_ID int64  `required:"True"`
@@ -30,8 +40,35 @@ type Config struct {
 	// mailing
 	MAILERSEND_API_KEY string `required:"True"`
 
+	// Rate limiting per client IP per form, forms can override it
+	RATE_LIMIT_BURST      int     `default:"10"`
+	RATE_LIMIT_PER_MINUTE float64 `default:"2"`
+
+	// CIDRs of the reverse proxies in front of goforms, the client IP is only
+	// read from X-Forwarded-For when the request comes from one of them.
+	// Without any the IP of the connection is used.
+	TRUSTED_PROXIES []string
+
+	// Spam classifier, trained by labeling mails as spam or ham
+	SPAM_CLASSIFIER_THRESHOLD     float64 `default:"0.95"`
+	SPAM_CLASSIFIER_MIN_DOCUMENTS int     `default:"10"`
+
+	// Larger posts to a form are refused, eg. 512K or 2M
+	MAX_FORM_SIZE string `default:"1M"`
+
+	// Identical submissions to a form within this window are not sent again
+	DUPLICATE_WINDOW time.Duration `default:"10m"`
+
+	// Mails and submissions older than RETENTION are purged every
+	// RETENTION_INTERVAL, forms can override it. 0 keeps them forever.
+	RETENTION                 time.Duration `default:"0"`
+	RETENTION_INTERVAL        time.Duration `default:"1h"`
+	RETENTION_KEEP_STATISTICS bool          `default:"true"`
+
 	// GOOGLE RECAPTCHA
 	GOOGLE_RECAPTCHA_SECRET_KEY string `required:"True"`
+	// Shown on the hosted form pages
+	GOOGLE_RECAPTCHA_SITE_KEY string
 
 	// Static assets, it should not be necessary to change these defaults.
 	STATIC_DIRECTORY           string `default:"static"`
@@ -84,6 +121,12 @@ func (c *Config) VerifyApiKey(apiKey string) bool {
 
 }
 
+// Identifies the API key in the audit log without revealing it.
+func (c *Config) ApiKeyID() string {
+	sum := sha256.Sum256([]byte(c.API_KEY))
+	return hex.EncodeToString(sum[:4])
+}
+
 func New() *Config {
 
 	config := Config{}
@@ -11,6 +11,36 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const abandonMail = `-- name: AbandonMail :execrows
+UPDATE
+    mails
+SET
+    error = $2,
+    idempotency_key = NULL,
+    fingerprint = NULL
+WHERE
+    id = $1
+    AND NOT success
+    AND NOT spam
+    AND COALESCE(error, '') = ''
+`
+
+type AbandonMailParams struct {
+	ID    int32
+	Error pgtype.Text
+}
+
+// Gives up on a mail that is still pending, it was left behind by a crash
+// between storing and sending it. Frees its idempotency key and fingerprint so
+// a retry can be sent.
+func (q *Queries) AbandonMail(ctx context.Context, arg AbandonMailParams) (int64, error) {
+	result, err := q.db.Exec(ctx, abandonMail, arg.ID, arg.Error)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const insertMail = `-- name: InsertMail :one
 INSERT INTO mails (
     -- COLUMS --
@@ -21,7 +51,22 @@ INSERT INTO mails (
     recipients, --
     subject, --
     content, --
-    error --
+    error, --
+    form_id, --
+    spam, --
+    spam_score, --
+    spam_reasons, --
+    fingerprint, --
+    idempotency_key, --
+    captcha_score, --
+    submission_id, --
+    client_ip, --
+    user_agent, --
+    origin, --
+    language, --
+    country, --
+    request_id, --
+    blind_index --
 )
     VALUES (
         -- VALUES --
@@ -32,21 +77,51 @@ INSERT INTO mails (
         $5, --
         $6, --
         $7, --
-        $8 --
+        $8, --
+        $9, --
+        $10, --
+        $11, --
+        $12, --
+        $13, --
+        $14, --
+        $15, --
+        $16, --
+        $17, --
+        $18, --
+        $19, --
+        $20, --
+        $21, --
+        $22, --
+        $23 --
 )
 RETURNING
     id
 `
 
 type InsertMailParams struct {
-	CreatedAt    pgtype.Timestamp
-	MailProvider string
-	MailFrom     string
-	Success      bool
-	Recipients   []string
-	Subject      string
-	Content      string
-	Error        pgtype.Text
+	CreatedAt      pgtype.Timestamp
+	MailProvider   string
+	MailFrom       string
+	Success        bool
+	Recipients     []string
+	Subject        string
+	Content        string
+	Error          pgtype.Text
+	FormID         pgtype.UUID
+	Spam           bool
+	SpamScore      float64
+	SpamReasons    []string
+	Fingerprint    pgtype.Text
+	IdempotencyKey pgtype.Text
+	CaptchaScore   pgtype.Float8
+	SubmissionID   pgtype.Int4
+	ClientIp       pgtype.Text
+	UserAgent      pgtype.Text
+	Origin         pgtype.Text
+	Language       pgtype.Text
+	Country        pgtype.Text
+	RequestID      pgtype.Text
+	BlindIndex     []string
 }
 
 func (q *Queries) InsertMail(ctx context.Context, arg InsertMailParams) (int32, error) {
@@ -59,8 +134,45 @@ func (q *Queries) InsertMail(ctx context.Context, arg InsertMailParams) (int32,
 		arg.Subject,
 		arg.Content,
 		arg.Error,
+		arg.FormID,
+		arg.Spam,
+		arg.SpamScore,
+		arg.SpamReasons,
+		arg.Fingerprint,
+		arg.IdempotencyKey,
+		arg.CaptchaScore,
+		arg.SubmissionID,
+		arg.ClientIp,
+		arg.UserAgent,
+		arg.Origin,
+		arg.Language,
+		arg.Country,
+		arg.RequestID,
+		arg.BlindIndex,
 	)
 	var id int32
 	err := row.Scan(&id)
 	return id, err
 }
+
+const setMailResult = `-- name: SetMailResult :exec
+UPDATE
+    mails
+SET
+    success = $2,
+    error = $3
+WHERE
+    id = $1
+`
+
+type SetMailResultParams struct {
+	ID      int32
+	Success bool
+	Error   pgtype.Text
+}
+
+// Records the outcome of sending a mail that was stored before it was sent.
+func (q *Queries) SetMailResult(ctx context.Context, arg SetMailResultParams) error {
+	_, err := q.db.Exec(ctx, setMailResult, arg.ID, arg.Success, arg.Error)
+	return err
+}
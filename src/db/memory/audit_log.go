@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/sevaho/goforms/src/db"
+)
+
+func (q *Queries) InsertAuditLog(ctx context.Context, arg db.InsertAuditLogParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.lastAuditLogID++
+	q.auditLog = append(q.auditLog, db.AuditLog{
+		ID:        q.lastAuditLogID,
+		CreatedAt: arg.CreatedAt,
+		Actor:     arg.Actor,
+		Action:    arg.Action,
+		Resource:  arg.Resource,
+		RecordIds: intArray(arg.RecordIds),
+		Subject:   arg.Subject,
+		ClientIp:  arg.ClientIp,
+		RequestID: arg.RequestID,
+		Filter:    arg.Filter,
+		Records:   arg.Records,
+	})
+	return nil
+}
+
+// Entries matching the filters that are set, newest first.
+func (q *Queries) SelectAuditLog(ctx context.Context, arg db.SelectAuditLogParams) ([]db.AuditLog, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var items []db.AuditLog
+	for i := len(q.auditLog) - 1; i >= 0 && len(items) < int(arg.Limit); i-- {
+		entry := q.auditLog[i]
+		switch {
+		case arg.CreatedAfter.Valid && before(entry.CreatedAt, arg.CreatedAfter):
+			continue
+		case arg.CreatedBefore.Valid && !before(entry.CreatedAt, arg.CreatedBefore):
+			continue
+		case arg.Actor.Valid && entry.Actor != arg.Actor.String:
+			continue
+		case arg.Action.Valid && entry.Action != arg.Action.String:
+			continue
+		case arg.Resource.Valid && entry.Resource != arg.Resource.String:
+			continue
+		case arg.RecordID.Valid && !slices.Contains(entry.RecordIds, arg.RecordID.Int32):
+			continue
+		case arg.CursorID.Valid && entry.ID >= arg.CursorID.Int32:
+			continue
+		}
+		entry.RecordIds = intArray(entry.RecordIds)
+		items = append(items, entry)
+	}
+	return items, nil
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/sevaho/goforms/src/db"
+)
+
+func (q *Queries) SelectClassifierTokens(ctx context.Context, tokens []string) ([]db.ClassifierToken, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var items []db.ClassifierToken
+	for _, token := range tokens {
+		if i, ok := q.classifierTokens[token]; ok {
+			items = append(items, i)
+		}
+	}
+	return items, nil
+}
+
+func (q *Queries) SelectClassifierDocuments(ctx context.Context) ([]db.ClassifierDocument, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var items []db.ClassifierDocument
+	for label, documents := range q.classifierDocument {
+		items = append(items, db.ClassifierDocument{Label: label, Documents: documents})
+	}
+	return items, nil
+}
+
+// Counts never go below zero, like the GREATEST (0, ...) of the queries.
+func (q *Queries) UpdateClassifierTokens(ctx context.Context, arg db.UpdateClassifierTokensParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, token := range arg.Tokens {
+		i := q.classifierTokens[token]
+		q.classifierTokens[token] = db.ClassifierToken{
+			Token: token,
+			Spam:  max(0, i.Spam+arg.Spam),
+			Ham:   max(0, i.Ham+arg.Ham),
+		}
+	}
+	return nil
+}
+
+func (q *Queries) UpdateClassifierDocuments(ctx context.Context, arg db.UpdateClassifierDocumentsParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.classifierDocument[arg.Label] = max(0, q.classifierDocument[arg.Label]+arg.Documents)
+	return nil
+}
@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+)
+
+func (q *Queries) InsertIPRule(ctx context.Context, arg db.InsertIPRuleParams) (db.IpRule, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.lastIPRuleID++
+	rule := db.IpRule{
+		ID:        q.lastIPRuleID,
+		CreatedAt: arg.CreatedAt,
+		Cidr:      arg.Cidr,
+		Action:    arg.Action,
+		Comment:   arg.Comment,
+		ExpiresAt: arg.ExpiresAt,
+	}
+	q.ipRules = append(q.ipRules, rule)
+	return rule, nil
+}
+
+func (q *Queries) DeleteIPRule(ctx context.Context, id int32) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	total := len(q.ipRules)
+	q.ipRules = slices.DeleteFunc(q.ipRules, func(rule db.IpRule) bool { return rule.ID == id })
+	return int64(total - len(q.ipRules)), nil
+}
+
+func (q *Queries) SelectActiveIPRules(ctx context.Context, now pgtype.Timestamp) ([]db.IpRule, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var items []db.IpRule
+	for _, rule := range q.ipRules {
+		if !rule.ExpiresAt.Valid || after(rule.ExpiresAt, now) {
+			items = append(items, rule)
+		}
+	}
+	return items, nil
+}
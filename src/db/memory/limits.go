@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+)
+
+// Returns 0 when the challenge was used before.
+func (q *Queries) UseCaptchaChallenge(ctx context.Context, arg db.UseCaptchaChallengeParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.captchaChallenges[arg.ID]; ok {
+		return 0, nil
+	}
+	q.captchaChallenges[arg.ID] = arg.ExpiresAt
+	return 1, nil
+}
+
+func (q *Queries) DeleteExpiredCaptchaChallenges(ctx context.Context, expiresAt pgtype.Timestamp) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for id, challengeExpiresAt := range q.captchaChallenges {
+		if before(challengeExpiresAt, expiresAt) {
+			delete(q.captchaChallenges, id)
+		}
+	}
+	return nil
+}
+
+func (q *Queries) DeleteFullRateLimits(ctx context.Context, updatedAt pgtype.Timestamp) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var deleted int64
+	for bucket, limit := range q.rateLimits {
+		if before(limit.UpdatedAt, updatedAt) {
+			delete(q.rateLimits, bucket)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Refills the bucket for the time passed since the last request, then takes a
+// token if there is one.
+func (q *Queries) TakeRateLimitToken(ctx context.Context, arg db.TakeRateLimitTokenParams) (db.TakeRateLimitTokenRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	limit, ok := q.rateLimits[arg.Bucket]
+	if !ok {
+		limit = db.RateLimit{Bucket: arg.Bucket, Tokens: arg.Capacity - 1, Allowed: true}
+	} else {
+		elapsed := max(0, arg.Now.Time.Sub(limit.UpdatedAt.Time).Seconds())
+		tokens := min(arg.Capacity, limit.Tokens+elapsed*arg.RefillPerSecond)
+
+		limit.Allowed = tokens >= 1
+		if limit.Allowed {
+			tokens--
+		}
+		limit.Tokens = tokens
+	}
+	limit.UpdatedAt = arg.Now
+	q.rateLimits[arg.Bucket] = limit
+
+	return db.TakeRateLimitTokenRow{Tokens: limit.Tokens, Allowed: limit.Allowed}, nil
+}
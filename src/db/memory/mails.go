@@ -0,0 +1,365 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+)
+
+// Runs f on the stored mail with the ID, like an UPDATE ... WHERE id = $1.
+func (q *Queries) updateMail(id int32, f func(mail *db.Mail)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i := range q.mails {
+		if q.mails[i].ID == id {
+			f(&q.mails[i])
+			return
+		}
+	}
+}
+
+// Returns copies of the mails that match, newest first.
+func (q *Queries) selectMails(match func(mail db.Mail) bool) []db.Mail {
+	var items []db.Mail
+	for _, mail := range q.mails {
+		if match(mail) {
+			items = append(items, copyMail(mail))
+		}
+	}
+	slices.SortFunc(items, compareNewestFirst)
+	return items
+}
+
+func (q *Queries) submissionExists(id pgtype.Int4) bool {
+	if !id.Valid {
+		return true
+	}
+	return slices.ContainsFunc(q.submissions, func(s db.Submission) bool { return s.ID == id.Int32 })
+}
+
+func (q *Queries) InsertMail(ctx context.Context, arg db.InsertMailParams) (int32, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.submissionExists(arg.SubmissionID) {
+		return 0, errors.New("insert or update on table \"mails\" violates foreign key constraint \"mails_submission_id_fkey\"")
+	}
+
+	if arg.IdempotencyKey.Valid && slices.ContainsFunc(q.mails, func(mail db.Mail) bool {
+		return mail.FormID == arg.FormID && mail.IdempotencyKey == arg.IdempotencyKey
+	}) {
+		return 0, &pgconn.PgError{Code: "23505", Message: "duplicate key value violates unique constraint \"mails_form_id_idempotency_key_key\""}
+	}
+
+	q.lastMailID++
+	q.mails = append(q.mails, copyMail(db.Mail{
+		ID:             q.lastMailID,
+		CreatedAt:      arg.CreatedAt,
+		MailProvider:   arg.MailProvider,
+		Success:        arg.Success,
+		MailFrom:       arg.MailFrom,
+		Recipients:     arg.Recipients,
+		Subject:        arg.Subject,
+		Content:        arg.Content,
+		Error:          arg.Error,
+		FormID:         arg.FormID,
+		Spam:           arg.Spam,
+		SpamScore:      arg.SpamScore,
+		SpamReasons:    arg.SpamReasons,
+		Fingerprint:    arg.Fingerprint,
+		IdempotencyKey: arg.IdempotencyKey,
+		CaptchaScore:   arg.CaptchaScore,
+		SubmissionID:   arg.SubmissionID,
+		ClientIp:       arg.ClientIp,
+		UserAgent:      arg.UserAgent,
+		Origin:         arg.Origin,
+		Language:       arg.Language,
+		Country:        arg.Country,
+		RequestID:      arg.RequestID,
+		BlindIndex:     arg.BlindIndex,
+	}))
+	return q.lastMailID, nil
+}
+
+func (q *Queries) SelectMailByID(ctx context.Context, id int32) (db.Mail, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, mail := range q.mails {
+		if mail.ID == id {
+			return copyMail(mail), nil
+		}
+	}
+	return db.Mail{}, pgx.ErrNoRows
+}
+
+func (q *Queries) SelectAllMails(ctx context.Context, arg db.SelectAllMailsParams) ([]db.Mail, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items := q.selectMails(func(mail db.Mail) bool { return mail.Spam == arg.Spam })
+	return page(items, arg.Offset, arg.Limit), nil
+}
+
+func (q *Queries) CountAllMails(ctx context.Context, spam bool) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var count int64
+	for _, mail := range q.mails {
+		if mail.Spam == spam {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Filters that are NULL match every mail, like the Postgres query.
+func searchMailsMatch(arg db.CountSearchMailsParams) func(mail db.Mail) bool {
+	return func(mail db.Mail) bool {
+		switch {
+		case arg.CreatedAfter.Valid && before(mail.CreatedAt, arg.CreatedAfter):
+			return false
+		case arg.CreatedBefore.Valid && !before(mail.CreatedAt, arg.CreatedBefore):
+			return false
+		case arg.FormID.Valid && !uuidEquals(mail.FormID, arg.FormID):
+			return false
+		case arg.MailProvider.Valid && mail.MailProvider != arg.MailProvider.String:
+			return false
+		case arg.Success.Valid && mail.Success != arg.Success.Bool:
+			return false
+		case arg.Spam.Valid && mail.Spam != arg.Spam.Bool:
+			return false
+		case arg.BlindIndex.Valid && !slices.Contains(mail.BlindIndex, arg.BlindIndex.String):
+			return false
+		}
+		return true
+	}
+}
+
+func (q *Queries) SearchMails(ctx context.Context, arg db.SearchMailsParams) ([]db.Mail, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	match := searchMailsMatch(db.CountSearchMailsParams{
+		CreatedAfter:  arg.CreatedAfter,
+		CreatedBefore: arg.CreatedBefore,
+		FormID:        arg.FormID,
+		MailProvider:  arg.MailProvider,
+		Success:       arg.Success,
+		Spam:          arg.Spam,
+		BlindIndex:    arg.BlindIndex,
+	})
+	cursor := db.Mail{CreatedAt: arg.CursorCreatedAt, ID: arg.CursorID.Int32}
+
+	items := q.selectMails(func(mail db.Mail) bool {
+		// (created_at, id) < (cursor_created_at, cursor_id)
+		if arg.CursorCreatedAt.Valid && compareNewestFirst(mail, cursor) <= 0 {
+			return false
+		}
+		return match(mail)
+	})
+	return page(items, arg.Offset, arg.Limit), nil
+}
+
+func (q *Queries) CountSearchMails(ctx context.Context, arg db.CountSearchMailsParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return int64(len(q.selectMails(searchMailsMatch(arg)))), nil
+}
+
+func (q *Queries) SelectDuplicateMail(ctx context.Context, arg db.SelectDuplicateMailParams) (db.Mail, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items := q.selectMails(func(mail db.Mail) bool {
+		return uuidEquals(mail.FormID, arg.FormID) && textEquals(mail.Fingerprint, arg.Fingerprint) && after(mail.CreatedAt, arg.CreatedAt)
+	})
+	if len(items) == 0 {
+		return db.Mail{}, pgx.ErrNoRows
+	}
+	return items[0], nil
+}
+
+func (q *Queries) SelectMailByIdempotencyKey(ctx context.Context, arg db.SelectMailByIdempotencyKeyParams) (db.Mail, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items := q.selectMails(func(mail db.Mail) bool {
+		return uuidEquals(mail.FormID, arg.FormID) && textEquals(mail.IdempotencyKey, arg.IdempotencyKey)
+	})
+	if len(items) == 0 {
+		return db.Mail{}, pgx.ErrNoRows
+	}
+	return items[0], nil
+}
+
+func (q *Queries) IncrementMailDuplicates(ctx context.Context, arg db.IncrementMailDuplicatesParams) error {
+	q.updateMail(arg.ID, func(mail *db.Mail) {
+		mail.Duplicates++
+		mail.LastDuplicateAt = arg.LastDuplicateAt
+	})
+	return nil
+}
+
+func (q *Queries) ReleaseMail(ctx context.Context, arg db.ReleaseMailParams) (int64, error) {
+	var updated int64
+	q.updateMail(arg.ID, func(mail *db.Mail) {
+		if !mail.Spam {
+			return
+		}
+		mail.Spam = false
+		mail.ReleasedAt = arg.ReleasedAt
+		mail.Success = false
+		mail.Error = pgtype.Text{}
+		updated = 1
+	})
+	return updated, nil
+}
+
+func (q *Queries) AbandonMail(ctx context.Context, arg db.AbandonMailParams) (int64, error) {
+	var updated int64
+	q.updateMail(arg.ID, func(mail *db.Mail) {
+		if mail.Success || mail.Spam || mail.Error.String != "" {
+			return
+		}
+		mail.Error = arg.Error
+		mail.IdempotencyKey = pgtype.Text{}
+		mail.Fingerprint = pgtype.Text{}
+		updated = 1
+	})
+	return updated, nil
+}
+
+// Records the outcome of sending a mail that was stored before it was sent.
+func (q *Queries) SetMailResult(ctx context.Context, arg db.SetMailResultParams) error {
+	q.updateMail(arg.ID, func(mail *db.Mail) {
+		mail.Success = arg.Success
+		mail.Error = arg.Error
+	})
+	return nil
+}
+
+func (q *Queries) SetMailClassification(ctx context.Context, arg db.SetMailClassificationParams) (int64, error) {
+	var updated int64
+	q.updateMail(arg.ID, func(mail *db.Mail) {
+		if mail.ClassifiedAs != arg.PreviousClassifiedAs {
+			return
+		}
+		mail.ClassifiedAs = arg.ClassifiedAs
+		updated = 1
+	})
+	return updated, nil
+}
+
+func (q *Queries) SelectMailsAfterID(ctx context.Context, arg db.SelectMailsAfterIDParams) ([]db.Mail, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Mails are appended in ID order
+	var items []db.Mail
+	for _, mail := range q.mails {
+		if mail.ID > arg.AfterID {
+			items = append(items, copyMail(mail))
+		}
+	}
+	return page(items, 0, arg.Limit), nil
+}
+
+func (q *Queries) UpdateMailCiphertexts(ctx context.Context, arg db.UpdateMailCiphertextsParams) (int64, error) {
+	var updated int64
+	q.updateMail(arg.ID, func(mail *db.Mail) {
+		if mail.Content != arg.PreviousContent {
+			return
+		}
+		updated = 1
+		mail.MailFrom = arg.MailFrom
+		mail.Recipients = textArray(arg.Recipients)
+		mail.Subject = arg.Subject
+		mail.Content = arg.Content
+		mail.ClientIp = arg.ClientIp
+		mail.UserAgent = arg.UserAgent
+		mail.Origin = arg.Origin
+	})
+	return updated, nil
+}
+
+func (q *Queries) UpdateMailBlindIndex(ctx context.Context, arg db.UpdateMailBlindIndexParams) error {
+	q.updateMail(arg.ID, func(mail *db.Mail) {
+		mail.BlindIndex = textArray(arg.BlindIndex)
+	})
+	return nil
+}
+
+func (q *Queries) SelectMailIDsBySubmissionID(ctx context.Context, submissionID pgtype.Int4) ([]int32, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var items []int32
+	for _, mail := range q.mails {
+		if submissionID.Valid && mail.SubmissionID.Valid && mail.SubmissionID.Int32 == submissionID.Int32 {
+			items = append(items, mail.ID)
+		}
+	}
+	return items, nil
+}
+
+func (q *Queries) SelectSubjectMails(ctx context.Context, hash string) ([]db.Mail, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var items []db.Mail
+	for _, mail := range q.mails {
+		if slices.Contains(mail.BlindIndex, hash) {
+			items = append(items, copyMail(mail))
+		}
+	}
+	return items, nil
+}
+
+func (q *Queries) DeleteMailsByID(ctx context.Context, ids []int32) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	total := len(q.mails)
+	q.mails = slices.DeleteFunc(q.mails, func(mail db.Mail) bool { return slices.Contains(ids, mail.ID) })
+	return int64(total - len(q.mails)), nil
+}
+
+// Wipes everything personal of the mails, the rows stay for the statistics.
+func (q *Queries) ShredMailsByID(ctx context.Context, ids []int32) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var count int64
+	for i := range q.mails {
+		mail := &q.mails[i]
+		if !slices.Contains(ids, mail.ID) {
+			continue
+		}
+		mail.MailFrom = ""
+		mail.Recipients = []string{}
+		mail.Subject = ""
+		mail.Content = ""
+		mail.ClientIp = pgtype.Text{}
+		mail.UserAgent = pgtype.Text{}
+		mail.Origin = pgtype.Text{}
+		mail.Fingerprint = pgtype.Text{}
+		mail.IdempotencyKey = pgtype.Text{}
+		mail.SubmissionID = pgtype.Int4{}
+		mail.SpamReasons = []string{}
+		mail.Language = pgtype.Text{}
+		mail.Country = pgtype.Text{}
+		mail.RequestID = pgtype.Text{}
+		mail.BlindIndex = []string{}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+)
+
+// Rows expire when they are older than the cutoff of their form, forms without
+// a cutoff use the default cutoff.
+func expired(formIDs []pgtype.UUID, cutoffs []pgtype.Timestamp, defaultCutoff pgtype.Timestamp) func(createdAt pgtype.Timestamp, formID pgtype.UUID) bool {
+	return func(createdAt pgtype.Timestamp, formID pgtype.UUID) bool {
+		cutoff := defaultCutoff
+		for i, id := range formIDs {
+			if i < len(cutoffs) && uuidEquals(id, formID) && cutoffs[i].Valid {
+				cutoff = cutoffs[i]
+				break
+			}
+		}
+		return before(createdAt, cutoff)
+	}
+}
+
+func (q *Queries) CountExpiredMails(ctx context.Context, arg db.CountExpiredMailsParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	isExpired := expired(arg.FormIds, arg.Cutoffs, arg.DefaultCutoff)
+
+	var count int64
+	for _, mail := range q.mails {
+		if isExpired(mail.CreatedAt, mail.FormID) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Deletes the expired mails and, when keep_statistics is set, adds them to the
+// statistics. Returns the amount of deleted mails.
+func (q *Queries) DeleteExpiredMails(ctx context.Context, arg db.DeleteExpiredMailsParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	isExpired := expired(arg.FormIds, arg.Cutoffs, arg.DefaultCutoff)
+
+	total := len(q.mails)
+	q.mails = slices.DeleteFunc(q.mails, func(mail db.Mail) bool {
+		if !isExpired(mail.CreatedAt, mail.FormID) {
+			return false
+		}
+		if arg.KeepStatistics {
+			t := mail.CreatedAt.Time
+			key := statisticKey{
+				day:     time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC),
+				spam:    mail.Spam,
+				success: mail.Success,
+			}
+			// the nil UUID for mails without a form
+			if mail.FormID.Valid {
+				key.formID = mail.FormID.Bytes
+			}
+			q.statistics[key]++
+		}
+		return true
+	})
+	return int64(total - len(q.mails)), nil
+}
+
+func (q *Queries) CountExpiredSubmissions(ctx context.Context, arg db.CountExpiredSubmissionsParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	isExpired := expired(arg.FormIds, arg.Cutoffs, arg.DefaultCutoff)
+
+	var count int64
+	for _, submission := range q.submissions {
+		if isExpired(submission.CreatedAt, submission.FormID) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *Queries) DeleteExpiredSubmissions(ctx context.Context, arg db.DeleteExpiredSubmissionsParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	isExpired := expired(arg.FormIds, arg.Cutoffs, arg.DefaultCutoff)
+	return q.deleteSubmissions(func(submission db.Submission) bool {
+		return isExpired(submission.CreatedAt, submission.FormID)
+	}), nil
+}
+
+func (q *Queries) InsertPurgeRun(ctx context.Context, arg db.InsertPurgeRunParams) (db.PurgeRun, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.lastPurgeRunID++
+	run := db.PurgeRun{
+		ID:          q.lastPurgeRunID,
+		StartedAt:   arg.StartedAt,
+		FinishedAt:  arg.FinishedAt,
+		DryRun:      arg.DryRun,
+		Mails:       arg.Mails,
+		Submissions: arg.Submissions,
+		Error:       arg.Error,
+	}
+	q.purgeRuns = append(q.purgeRuns, run)
+	return run, nil
+}
+
+func (q *Queries) SelectPurgeRuns(ctx context.Context, limit int32) ([]db.PurgeRun, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items := slices.Clone(q.purgeRuns)
+	slices.Reverse(items)
+	return page(items, 0, limit), nil
+}
+
+// A memory store is never shared between replicas.
+func (q *Queries) TryAdvisoryXactLock(ctx context.Context, key int64) (bool, error) {
+	return true, nil
+}
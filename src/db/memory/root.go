@@ -0,0 +1,194 @@
+// Package memory keeps goforms in memory for development and tests, nothing
+// survives a restart. It implements db.Querier with the same behaviour as the
+// queries sqlc generates for Postgres.
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+type statisticKey struct {
+	day     time.Time
+	formID  uuid.UUID
+	spam    bool
+	success bool
+}
+
+// The stored rows, a transaction restores a copy of them when it fails.
+type data struct {
+	mails              []db.Mail
+	submissions        []db.Submission
+	ipRules            []db.IpRule
+	purgeRuns          []db.PurgeRun
+	subjectRequests    []db.SubjectRequest
+	auditLog           []db.AuditLog
+	rateLimits         map[string]db.RateLimit
+	captchaChallenges  map[string]pgtype.Timestamp
+	classifierTokens   map[string]db.ClassifierToken
+	classifierDocument map[string]int32
+	statistics         map[statisticKey]int64
+
+	// Identities, they are not reused after a delete like in Postgres
+	lastMailID       int32
+	lastSubmissionID int32
+	lastIPRuleID     int32
+	lastPurgeRunID   int32
+	lastSubjectID    int32
+	lastAuditLogID   int32
+}
+
+// Rows are values, the arrays in them are replaced instead of changed in place
+// so a shallow copy of every row is enough.
+func (d *data) clone() data {
+	c := *d
+	c.mails = slices.Clone(d.mails)
+	c.submissions = slices.Clone(d.submissions)
+	c.ipRules = slices.Clone(d.ipRules)
+	c.purgeRuns = slices.Clone(d.purgeRuns)
+	c.subjectRequests = slices.Clone(d.subjectRequests)
+	c.auditLog = slices.Clone(d.auditLog)
+	c.rateLimits = maps.Clone(d.rateLimits)
+	c.captchaChallenges = maps.Clone(d.captchaChallenges)
+	c.classifierTokens = maps.Clone(d.classifierTokens)
+	c.classifierDocument = maps.Clone(d.classifierDocument)
+	c.statistics = maps.Clone(d.statistics)
+	return c
+}
+
+type Queries struct {
+	// Every query holds the lock, in a transaction it is held by InTx instead
+	mu sync.Locker
+	*data
+}
+
+// The lock of the queries in a transaction, InTx already holds the real one.
+type noLock struct{}
+
+func (noLock) Lock()   {}
+func (noLock) Unlock() {}
+
+var _ db.Store = (*Queries)(nil)
+
+// Whether the DSN is for the in-memory store, eg. memory://
+func IsDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "memory:")
+}
+
+func New() *Queries {
+	logger.Logger.Warn().Msg("[DB] using the in-memory store, nothing is kept after a restart")
+
+	return &Queries{
+		mu: &sync.Mutex{},
+		data: &data{
+			rateLimits:         map[string]db.RateLimit{},
+			captchaChallenges:  map[string]pgtype.Timestamp{},
+			classifierTokens:   map[string]db.ClassifierToken{},
+			classifierDocument: map[string]int32{},
+			statistics:         map[statisticKey]int64{},
+		},
+	}
+}
+
+// Transactions hold the lock until they are done, so they are serializable,
+// and put back the rows they started with when f fails.
+func (q *Queries) InTx(ctx context.Context, f func(q db.Querier) error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	snapshot := q.data.clone()
+	if err := f(&Queries{mu: noLock{}, data: q.data}); err != nil {
+		*q.data = snapshot
+		return err
+	}
+	return nil
+}
+
+// Arrays that are NOT NULL come back empty instead of nil from Postgres, the
+// stored arrays are copies so callers can not change them.
+func textArray(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return slices.Clone(values)
+}
+
+func intArray(values []int32) []int32 {
+	if values == nil {
+		return []int32{}
+	}
+	return slices.Clone(values)
+}
+
+func copyMail(mail db.Mail) db.Mail {
+	mail.Recipients = textArray(mail.Recipients)
+	mail.SpamReasons = textArray(mail.SpamReasons)
+	mail.BlindIndex = textArray(mail.BlindIndex)
+	return mail
+}
+
+// NULL never equals anything, like in SQL.
+func uuidEquals(a pgtype.UUID, b pgtype.UUID) bool {
+	return a.Valid && b.Valid && a.Bytes == b.Bytes
+}
+
+func textEquals(a pgtype.Text, b pgtype.Text) bool {
+	return a.Valid && b.Valid && a.String == b.String
+}
+
+// Compares a timestamp to one that can be infinite, the retention uses
+// -infinity as "never expires".
+func before(t pgtype.Timestamp, cutoff pgtype.Timestamp) bool {
+	if !t.Valid || !cutoff.Valid {
+		return false
+	}
+	switch cutoff.InfinityModifier {
+	case pgtype.NegativeInfinity:
+		return false
+	case pgtype.Infinity:
+		return true
+	}
+	return t.Time.Before(cutoff.Time)
+}
+
+func after(t pgtype.Timestamp, cutoff pgtype.Timestamp) bool {
+	if !t.Valid || !cutoff.Valid {
+		return false
+	}
+	switch cutoff.InfinityModifier {
+	case pgtype.NegativeInfinity:
+		return true
+	case pgtype.Infinity:
+		return false
+	}
+	return t.Time.After(cutoff.Time)
+}
+
+// Newest first, the ID breaks ties.
+func compareNewestFirst(a db.Mail, b db.Mail) int {
+	if c := b.CreatedAt.Time.Compare(a.CreatedAt.Time); c != 0 {
+		return c
+	}
+	return int(b.ID) - int(a.ID)
+}
+
+// Applies OFFSET and LIMIT.
+func page[T any](items []T, offset int32, limit int32) []T {
+	if int(offset) >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if int(limit) < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+)
+
+// Like date_trunc, weeks start on monday.
+func dateTrunc(bucket string, t time.Time) time.Time {
+	t = t.UTC()
+	switch bucket {
+	case "hour":
+		return t.Truncate(time.Hour)
+	case "week":
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func inRange(t pgtype.Timestamp, createdAfter pgtype.Timestamp, createdBefore pgtype.Timestamp) bool {
+	return !before(t, createdAfter) && before(t, createdBefore)
+}
+
+// Orders by bucket and form, NULL forms last like in Postgres.
+func compareBucketForm(aBucket pgtype.Timestamp, aForm pgtype.UUID, bBucket pgtype.Timestamp, bForm pgtype.UUID) int {
+	if c := aBucket.Time.Compare(bBucket.Time); c != 0 {
+		return c
+	}
+	if aForm.Valid != bForm.Valid {
+		if aForm.Valid {
+			return -1
+		}
+		return 1
+	}
+	return bytes.Compare(aForm.Bytes[:], bForm.Bytes[:])
+}
+
+func (q *Queries) CountMailsByBucket(ctx context.Context, arg db.CountMailsByBucketParams) ([]db.CountMailsByBucketRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	type key struct {
+		bucket   time.Time
+		formID   pgtype.UUID
+		provider string
+	}
+	groups := map[key]*db.CountMailsByBucketRow{}
+
+	for _, mail := range q.mails {
+		if !inRange(mail.CreatedAt, arg.CreatedAfter, arg.CreatedBefore) {
+			continue
+		}
+		k := key{dateTrunc(arg.Bucket, mail.CreatedAt.Time), mail.FormID, mail.MailProvider}
+		row, ok := groups[k]
+		if !ok {
+			row = &db.CountMailsByBucketRow{
+				Bucket:       pgtype.Timestamp{Time: k.bucket, Valid: true},
+				FormID:       k.formID,
+				MailProvider: k.provider,
+			}
+			groups[k] = row
+		}
+		row.Mails++
+		if mail.Spam {
+			row.Spam++
+		}
+		if mail.Success {
+			row.Success++
+		}
+	}
+
+	var items []db.CountMailsByBucketRow
+	for _, row := range groups {
+		items = append(items, *row)
+	}
+	slices.SortFunc(items, func(a, b db.CountMailsByBucketRow) int {
+		if c := compareBucketForm(a.Bucket, a.FormID, b.Bucket, b.FormID); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.MailProvider, b.MailProvider)
+	})
+	return items, nil
+}
+
+func (q *Queries) CountPurgedMailsByBucket(ctx context.Context, arg db.CountPurgedMailsByBucketParams) ([]db.CountPurgedMailsByBucketRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	type key struct {
+		bucket time.Time
+		formID pgtype.UUID
+	}
+	groups := map[key]*db.CountPurgedMailsByBucketRow{}
+
+	for statistic, mails := range q.statistics {
+		if !inRange(pgtype.Timestamp{Time: statistic.day, Valid: true}, arg.CreatedAfter, arg.CreatedBefore) {
+			continue
+		}
+		k := key{dateTrunc(arg.Bucket, statistic.day), pgtype.UUID{Bytes: statistic.formID, Valid: true}}
+		row, ok := groups[k]
+		if !ok {
+			row = &db.CountPurgedMailsByBucketRow{
+				Bucket: pgtype.Timestamp{Time: k.bucket, Valid: true},
+				FormID: k.formID,
+			}
+			groups[k] = row
+		}
+		row.Mails += mails
+		if statistic.spam {
+			row.Spam += mails
+		}
+		if statistic.success {
+			row.Success += mails
+		}
+	}
+
+	var items []db.CountPurgedMailsByBucketRow
+	for _, row := range groups {
+		items = append(items, *row)
+	}
+	slices.SortFunc(items, func(a, b db.CountPurgedMailsByBucketRow) int {
+		return compareBucketForm(a.Bucket, a.FormID, b.Bucket, b.FormID)
+	})
+	return items, nil
+}
+
+func (q *Queries) SelectMailOrigins(ctx context.Context, arg db.SelectMailOriginsParams) ([]pgtype.Text, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var items []pgtype.Text
+	for _, mail := range q.mails {
+		if mail.Origin.Valid && inRange(mail.CreatedAt, arg.CreatedAfter, arg.CreatedBefore) {
+			items = append(items, mail.Origin)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+)
+
+func (q *Queries) InsertSubmission(ctx context.Context, arg db.InsertSubmissionParams) (int32, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.lastSubmissionID++
+	q.submissions = append(q.submissions, db.Submission{
+		ID:        q.lastSubmissionID,
+		CreatedAt: arg.CreatedAt,
+		FormID:    arg.FormID,
+		Fields:    arg.Fields,
+	})
+	return q.lastSubmissionID, nil
+}
+
+func (q *Queries) SelectSubmissionByID(ctx context.Context, id int32) (db.Submission, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, submission := range q.submissions {
+		if submission.ID == id {
+			return submission, nil
+		}
+	}
+	return db.Submission{}, pgx.ErrNoRows
+}
+
+func (q *Queries) SelectSubmissionsByID(ctx context.Context, ids []int32) ([]db.Submission, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var items []db.Submission
+	for _, submission := range q.submissions {
+		if slices.Contains(ids, submission.ID) {
+			items = append(items, submission)
+		}
+	}
+	return items, nil
+}
+
+func (q *Queries) SelectSubmissionsAfterID(ctx context.Context, arg db.SelectSubmissionsAfterIDParams) ([]db.Submission, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var items []db.Submission
+	for _, submission := range q.submissions {
+		if submission.ID > arg.AfterID {
+			items = append(items, submission)
+		}
+	}
+	return page(items, 0, arg.Limit), nil
+}
+
+func (q *Queries) UpdateSubmissionFields(ctx context.Context, arg db.UpdateSubmissionFieldsParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i := range q.submissions {
+		if q.submissions[i].ID == arg.ID && q.submissions[i].Fields == arg.PreviousFields {
+			q.submissions[i].Fields = arg.Fields
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// Deletes the submissions that match and unlinks their mails, like the
+// ON DELETE SET NULL of mails.submission_id.
+func (q *Queries) deleteSubmissions(match func(submission db.Submission) bool) int64 {
+	var deleted []int32
+	q.submissions = slices.DeleteFunc(q.submissions, func(submission db.Submission) bool {
+		if match(submission) {
+			deleted = append(deleted, submission.ID)
+			return true
+		}
+		return false
+	})
+
+	for i := range q.mails {
+		if q.mails[i].SubmissionID.Valid && slices.Contains(deleted, q.mails[i].SubmissionID.Int32) {
+			q.mails[i].SubmissionID = pgtype.Int4{}
+		}
+	}
+	return int64(len(deleted))
+}
+
+func (q *Queries) DeleteSubmissionsByID(ctx context.Context, ids []int32) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.deleteSubmissions(func(submission db.Submission) bool { return slices.Contains(ids, submission.ID) }), nil
+}
+
+func (q *Queries) InsertSubjectRequest(ctx context.Context, arg db.InsertSubjectRequestParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.lastSubjectID++
+	q.subjectRequests = append(q.subjectRequests, db.SubjectRequest{
+		ID:          q.lastSubjectID,
+		CreatedAt:   arg.CreatedAt,
+		Action:      arg.Action,
+		Subject:     arg.Subject,
+		Mails:       arg.Mails,
+		Submissions: arg.Submissions,
+	})
+	return nil
+}
@@ -8,6 +8,52 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// Who viewed, exported, resent or deleted which records, rows are never changed
+type AuditLog struct {
+	ID        int32
+	CreatedAt pgtype.Timestamp
+	// ID of the API key that was used
+	Actor     string
+	Action    string
+	Resource  string
+	RecordIds []int32
+	// Keyed hash of the email address of a data subject request
+	Subject   pgtype.Text
+	ClientIp  pgtype.Text
+	RequestID pgtype.Text
+	// Query of an export, without the email address and field value it searched for
+	Filter pgtype.Text
+	// Amount of records an export matched
+	Records pgtype.Int4
+}
+
+// Proof-of-work challenges that were used, to refuse replays
+type CaptchaChallenge struct {
+	ID        string
+	ExpiresAt pgtype.Timestamp
+}
+
+type ClassifierDocument struct {
+	Label     string
+	Documents int32
+}
+
+// Tokens are stored as keyed hashes so the model does not leak submission content
+type ClassifierToken struct {
+	Token string
+	Spam  int32
+	Ham   int32
+}
+
+type IpRule struct {
+	ID        int32
+	CreatedAt pgtype.Timestamp
+	Cidr      string
+	Action    string
+	Comment   pgtype.Text
+	ExpiresAt pgtype.Timestamp
+}
+
 type Mail struct {
 	ID           int32
 	CreatedAt    pgtype.Timestamp
@@ -22,4 +68,82 @@ type Mail struct {
 	// Encrypted email content
 	Content string
 	Error   pgtype.Text
+	FormID  pgtype.UUID
+	// Submission scored over the spam threshold and was not sent
+	Spam        bool
+	SpamScore   float64
+	SpamReasons []string
+	// When the submission was released from spam and sent after all
+	ReleasedAt pgtype.Timestamp
+	// Label an admin gave the mail to train the spam classifier
+	ClassifiedAs pgtype.Text
+	// Keyed hash of the normalized submitted fields
+	Fingerprint    pgtype.Text
+	IdempotencyKey pgtype.Text
+	// Amount of identical submissions that were suppressed
+	Duplicates      int32
+	LastDuplicateAt pgtype.Timestamp
+	// Score the captcha gave the submission, only reCAPTCHA v3 scores
+	CaptchaScore pgtype.Float8
+	SubmissionID pgtype.Int4
+	// Encrypted client IP address, truncated or hashed depending on the form
+	ClientIp pgtype.Text
+	// Encrypted user agent of the client
+	UserAgent pgtype.Text
+	// Encrypted origin or referer of the submission
+	Origin    pgtype.Text
+	Language  pgtype.Text
+	Country   pgtype.Text
+	RequestID pgtype.Text
+	// Keyed hashes of the normalized email addresses and searchable fields
+	BlindIndex []string
+}
+
+// Anonymized counts of the mails that were purged
+type MailStatistic struct {
+	Day pgtype.Date
+	// the nil UUID for mails without a form
+	FormID  pgtype.UUID
+	Spam    bool
+	Success bool
+	Mails   int64
+}
+
+// Every run of the retention job, to show auditors old data is purged
+type PurgeRun struct {
+	ID         int32
+	StartedAt  pgtype.Timestamp
+	FinishedAt pgtype.Timestamp
+	DryRun     bool
+	// amount of mails that were (or would be) deleted
+	Mails       int64
+	Submissions int64
+	Error       pgtype.Text
+}
+
+type RateLimit struct {
+	Bucket    string
+	Tokens    float64
+	Allowed   bool
+	UpdatedAt pgtype.Timestamp
+}
+
+// Exports and erasures of the data of a data subject
+type SubjectRequest struct {
+	ID        int32
+	CreatedAt pgtype.Timestamp
+	// export, delete or shred
+	Action string
+	// Keyed hash of the email address of the data subject
+	Subject     string
+	Mails       int64
+	Submissions int64
+}
+
+type Submission struct {
+	ID        int32
+	CreatedAt pgtype.Timestamp
+	FormID    pgtype.UUID
+	// Encrypted JSON array of the submitted fields in submission order
+	Fields string
 }
@@ -6,14 +6,92 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
-	CountAllMails(ctx context.Context) (int64, error)
-	FilterMailsOnCreatedAt(ctx context.Context, arg FilterMailsOnCreatedAtParams) ([]Mail, error)
+	// Gives up on a mail that is still pending, it was left behind by a crash
+	// between storing and sending it. Frees its idempotency key and fingerprint so
+	// a retry can be sent.
+	AbandonMail(ctx context.Context, arg AbandonMailParams) (int64, error)
+	CountAllMails(ctx context.Context, spam bool) (int64, error)
+	// Mails and submissions expire when they are older than the cutoff of their
+	// form, forms without a cutoff use the default cutoff.
+	CountExpiredMails(ctx context.Context, arg CountExpiredMailsParams) (int64, error)
+	CountExpiredSubmissions(ctx context.Context, arg CountExpiredSubmissionsParams) (int64, error)
+	// Mails per bucket, form and provider. The bucket is a date_trunc field, eg.
+	// hour, day, week or month.
+	CountMailsByBucket(ctx context.Context, arg CountMailsByBucketParams) ([]CountMailsByBucketRow, error)
+	// The statistics kept of the purged mails per bucket and form, they are per
+	// day so an hour bucket is the start of the day.
+	CountPurgedMailsByBucket(ctx context.Context, arg CountPurgedMailsByBucketParams) ([]CountPurgedMailsByBucketRow, error)
+	CountSearchMails(ctx context.Context, arg CountSearchMailsParams) (int64, error)
+	DeleteExpiredCaptchaChallenges(ctx context.Context, expiresAt pgtype.Timestamp) error
+	// Deletes the expired mails and, when keep_statistics is set, adds them to the
+	// statistics in the same statement. Returns the amount of deleted mails.
+	DeleteExpiredMails(ctx context.Context, arg DeleteExpiredMailsParams) (int64, error)
+	DeleteExpiredSubmissions(ctx context.Context, arg DeleteExpiredSubmissionsParams) (int64, error)
+	// A bucket that was not used for longer than it takes to refill is full, which
+	// is the same as having no bucket at all.
+	DeleteFullRateLimits(ctx context.Context, updatedAt pgtype.Timestamp) (int64, error)
+	DeleteIPRule(ctx context.Context, id int32) (int64, error)
+	DeleteMailsByID(ctx context.Context, ids []int32) (int64, error)
+	DeleteSubmissionsByID(ctx context.Context, ids []int32) (int64, error)
+	IncrementMailDuplicates(ctx context.Context, arg IncrementMailDuplicatesParams) error
+	InsertAuditLog(ctx context.Context, arg InsertAuditLogParams) error
+	InsertIPRule(ctx context.Context, arg InsertIPRuleParams) (IpRule, error)
 	InsertMail(ctx context.Context, arg InsertMailParams) (int32, error)
+	InsertPurgeRun(ctx context.Context, arg InsertPurgeRunParams) (PurgeRun, error)
+	InsertSubjectRequest(ctx context.Context, arg InsertSubjectRequestParams) error
+	InsertSubmission(ctx context.Context, arg InsertSubmissionParams) (int32, error)
+	// Takes the mail out of the spam before it is sent, returns 0 when it is not
+	// spam (anymore) so concurrent releases only send it once. The outcome of the
+	// send is recorded with SetMailResult.
+	ReleaseMail(ctx context.Context, arg ReleaseMailParams) (int64, error)
+	// Mails matching the filters that are set, newest first. The cursor is the
+	// created_at and id of the last mail of the previous page.
+	SearchMails(ctx context.Context, arg SearchMailsParams) ([]Mail, error)
+	SelectActiveIPRules(ctx context.Context, now pgtype.Timestamp) ([]IpRule, error)
 	SelectAllMails(ctx context.Context, arg SelectAllMailsParams) ([]Mail, error)
+	// Entries matching the filters that are set, newest first. The cursor is the
+	// id of the last entry of the previous page.
+	SelectAuditLog(ctx context.Context, arg SelectAuditLogParams) ([]AuditLog, error)
+	SelectClassifierDocuments(ctx context.Context) ([]ClassifierDocument, error)
+	SelectClassifierTokens(ctx context.Context, tokens []string) ([]ClassifierToken, error)
+	SelectDuplicateMail(ctx context.Context, arg SelectDuplicateMailParams) (Mail, error)
 	SelectMailByID(ctx context.Context, id int32) (Mail, error)
+	SelectMailByIdempotencyKey(ctx context.Context, arg SelectMailByIdempotencyKeyParams) (Mail, error)
+	SelectMailIDsBySubmissionID(ctx context.Context, submissionID pgtype.Int4) ([]int32, error)
+	// The encrypted origins of the mails, they are counted after decryption.
+	SelectMailOrigins(ctx context.Context, arg SelectMailOriginsParams) ([]pgtype.Text, error)
+	SelectMailsAfterID(ctx context.Context, arg SelectMailsAfterIDParams) ([]Mail, error)
+	SelectPurgeRuns(ctx context.Context, limit int32) ([]PurgeRun, error)
+	SelectSubjectMails(ctx context.Context, hash string) ([]Mail, error)
+	SelectSubmissionByID(ctx context.Context, id int32) (Submission, error)
+	SelectSubmissionsAfterID(ctx context.Context, arg SelectSubmissionsAfterIDParams) ([]Submission, error)
+	SelectSubmissionsByID(ctx context.Context, ids []int32) ([]Submission, error)
+	// Only updates the mail when it is still classified as previous_classified_as,
+	// returns 0 when someone else classified it in the meantime.
+	SetMailClassification(ctx context.Context, arg SetMailClassificationParams) (int64, error)
+	// Records the outcome of sending a mail that was stored before it was sent.
+	SetMailResult(ctx context.Context, arg SetMailResultParams) error
+	// Wipes everything personal of the mails, the rows stay for the statistics.
+	ShredMailsByID(ctx context.Context, ids []int32) (int64, error)
+	TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (TakeRateLimitTokenRow, error)
+	// Takes the advisory lock till the end of the transaction, returns false when
+	// another transaction holds it.
+	TryAdvisoryXactLock(ctx context.Context, key int64) (bool, error)
+	UpdateClassifierDocuments(ctx context.Context, arg UpdateClassifierDocumentsParams) error
+	UpdateClassifierTokens(ctx context.Context, arg UpdateClassifierTokensParams) error
+	UpdateMailBlindIndex(ctx context.Context, arg UpdateMailBlindIndexParams) error
+	// Only updates the mail when its content is still the ciphertext that was
+	// re-encrypted, returns 0 when the mail changed in the meantime, eg. was erased.
+	UpdateMailCiphertexts(ctx context.Context, arg UpdateMailCiphertextsParams) (int64, error)
+	// Only updates the submission when its fields are still the ciphertext that was
+	// re-encrypted, returns 0 when the submission changed in the meantime.
+	UpdateSubmissionFields(ctx context.Context, arg UpdateSubmissionFieldsParams) (int64, error)
+	UseCaptchaChallenge(ctx context.Context, arg UseCaptchaChallengeParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
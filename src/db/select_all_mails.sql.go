@@ -16,36 +16,120 @@ SELECT
     count(*)
 FROM
     mails
+WHERE
+    spam = $1
 `
 
-func (q *Queries) CountAllMails(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, countAllMails)
+func (q *Queries) CountAllMails(ctx context.Context, spam bool) (int64, error) {
+	row := q.db.QueryRow(ctx, countAllMails, spam)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
-const filterMailsOnCreatedAt = `-- name: FilterMailsOnCreatedAt :many
+const countSearchMails = `-- name: CountSearchMails :one
 SELECT
-    id, created_at, mail_provider, success, mail_from, recipients, subject, content, error
+    count(*)
 FROM
     mails
-WHERE
-    created_at > $2
-    AND created_at < $3
+WHERE ($1::timestamp IS NULL
+    OR created_at >= $1)
+AND ($2::timestamp IS NULL
+    OR created_at < $2)
+AND ($3::uuid IS NULL
+    OR form_id = $3)
+AND ($4::text IS NULL
+    OR mail_provider = $4)
+AND ($5::bool IS NULL
+    OR success = $5)
+AND ($6::bool IS NULL
+    OR spam = $6)
+AND ($7::text IS NULL
+    OR blind_index @> ARRAY[$7::text])
+`
+
+type CountSearchMailsParams struct {
+	CreatedAfter  pgtype.Timestamp
+	CreatedBefore pgtype.Timestamp
+	FormID        pgtype.UUID
+	MailProvider  pgtype.Text
+	Success       pgtype.Bool
+	Spam          pgtype.Bool
+	BlindIndex    pgtype.Text
+}
+
+func (q *Queries) CountSearchMails(ctx context.Context, arg CountSearchMailsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countSearchMails,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.FormID,
+		arg.MailProvider,
+		arg.Success,
+		arg.Spam,
+		arg.BlindIndex,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const searchMails = `-- name: SearchMails :many
+SELECT
+    id, created_at, mail_provider, success, mail_from, recipients, subject, content, error, form_id, spam, spam_score, spam_reasons, released_at, classified_as, fingerprint, idempotency_key, duplicates, last_duplicate_at, captcha_score, submission_id, client_ip, user_agent, origin, language, country, request_id, blind_index
+FROM
+    mails
+WHERE ($1::timestamp IS NULL
+    OR created_at >= $1)
+AND ($2::timestamp IS NULL
+    OR created_at < $2)
+AND ($3::uuid IS NULL
+    OR form_id = $3)
+AND ($4::text IS NULL
+    OR mail_provider = $4)
+AND ($5::bool IS NULL
+    OR success = $5)
+AND ($6::bool IS NULL
+    OR spam = $6)
+AND ($7::text IS NULL
+    OR blind_index @> ARRAY[$7::text])
+AND ($8::timestamp IS NULL
+    OR (created_at, id) < ($8, $9::int))
 ORDER BY
-    created_at DESC
-LIMIT $1
+    created_at DESC,
+    id DESC
+LIMIT $10 offset $11
 `
 
-type FilterMailsOnCreatedAtParams struct {
-	Limit       int32
-	CreatedAtLt pgtype.Timestamp
-	CreatedAtGt pgtype.Timestamp
+type SearchMailsParams struct {
+	CreatedAfter    pgtype.Timestamp
+	CreatedBefore   pgtype.Timestamp
+	FormID          pgtype.UUID
+	MailProvider    pgtype.Text
+	Success         pgtype.Bool
+	Spam            pgtype.Bool
+	BlindIndex      pgtype.Text
+	CursorCreatedAt pgtype.Timestamp
+	CursorID        pgtype.Int4
+	Limit           int32
+	Offset          int32
 }
 
-func (q *Queries) FilterMailsOnCreatedAt(ctx context.Context, arg FilterMailsOnCreatedAtParams) ([]Mail, error) {
-	rows, err := q.db.Query(ctx, filterMailsOnCreatedAt, arg.Limit, arg.CreatedAtLt, arg.CreatedAtGt)
+// Mails matching the filters that are set, newest first. The cursor is the
+// created_at and id of the last mail of the previous page.
+func (q *Queries) SearchMails(ctx context.Context, arg SearchMailsParams) ([]Mail, error) {
+	rows, err := q.db.Query(ctx, searchMails,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.FormID,
+		arg.MailProvider,
+		arg.Success,
+		arg.Spam,
+		arg.BlindIndex,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +147,25 @@ func (q *Queries) FilterMailsOnCreatedAt(ctx context.Context, arg FilterMailsOnC
 			&i.Subject,
 			&i.Content,
 			&i.Error,
+			&i.FormID,
+			&i.Spam,
+			&i.SpamScore,
+			&i.SpamReasons,
+			&i.ReleasedAt,
+			&i.ClassifiedAs,
+			&i.Fingerprint,
+			&i.IdempotencyKey,
+			&i.Duplicates,
+			&i.LastDuplicateAt,
+			&i.CaptchaScore,
+			&i.SubmissionID,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.Origin,
+			&i.Language,
+			&i.Country,
+			&i.RequestID,
+			&i.BlindIndex,
 		); err != nil {
 			return nil, err
 		}
@@ -76,21 +179,24 @@ func (q *Queries) FilterMailsOnCreatedAt(ctx context.Context, arg FilterMailsOnC
 
 const selectAllMails = `-- name: SelectAllMails :many
 SELECT
-    id, created_at, mail_provider, success, mail_from, recipients, subject, content, error
+    id, created_at, mail_provider, success, mail_from, recipients, subject, content, error, form_id, spam, spam_score, spam_reasons, released_at, classified_as, fingerprint, idempotency_key, duplicates, last_duplicate_at, captcha_score, submission_id, client_ip, user_agent, origin, language, country, request_id, blind_index
 FROM
     mails
+WHERE
+    spam = $1
 ORDER BY
     created_at DESC
-LIMIT $1 offset $2
+LIMIT $2 offset $3
 `
 
 type SelectAllMailsParams struct {
+	Spam   bool
 	Limit  int32
 	Offset int32
 }
 
 func (q *Queries) SelectAllMails(ctx context.Context, arg SelectAllMailsParams) ([]Mail, error) {
-	rows, err := q.db.Query(ctx, selectAllMails, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, selectAllMails, arg.Spam, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -108,6 +214,25 @@ func (q *Queries) SelectAllMails(ctx context.Context, arg SelectAllMailsParams)
 			&i.Subject,
 			&i.Content,
 			&i.Error,
+			&i.FormID,
+			&i.Spam,
+			&i.SpamScore,
+			&i.SpamReasons,
+			&i.ReleasedAt,
+			&i.ClassifiedAs,
+			&i.Fingerprint,
+			&i.IdempotencyKey,
+			&i.Duplicates,
+			&i.LastDuplicateAt,
+			&i.CaptchaScore,
+			&i.SubmissionID,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.Origin,
+			&i.Language,
+			&i.Country,
+			&i.RequestID,
+			&i.BlindIndex,
 		); err != nil {
 			return nil, err
 		}
@@ -11,7 +11,7 @@ import (
 
 const selectMailByID = `-- name: SelectMailByID :one
 SELECT
-    id, created_at, mail_provider, success, mail_from, recipients, subject, content, error
+    id, created_at, mail_provider, success, mail_from, recipients, subject, content, error, form_id, spam, spam_score, spam_reasons, released_at, classified_as, fingerprint, idempotency_key, duplicates, last_duplicate_at, captcha_score, submission_id, client_ip, user_agent, origin, language, country, request_id, blind_index
 FROM
     mails
 WHERE
@@ -31,6 +31,25 @@ func (q *Queries) SelectMailByID(ctx context.Context, id int32) (Mail, error) {
 		&i.Subject,
 		&i.Content,
 		&i.Error,
+		&i.FormID,
+		&i.Spam,
+		&i.SpamScore,
+		&i.SpamReasons,
+		&i.ReleasedAt,
+		&i.ClassifiedAs,
+		&i.Fingerprint,
+		&i.IdempotencyKey,
+		&i.Duplicates,
+		&i.LastDuplicateAt,
+		&i.CaptchaScore,
+		&i.SubmissionID,
+		&i.ClientIp,
+		&i.UserAgent,
+		&i.Origin,
+		&i.Language,
+		&i.Country,
+		&i.RequestID,
+		&i.BlindIndex,
 	)
 	return i, err
 }
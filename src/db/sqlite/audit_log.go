@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/sevaho/goforms/src/db"
+)
+
+const insertAuditLog = `INSERT INTO audit_log (created_at, actor, action, resource, record_ids, subject, client_ip, request_id, filter, records)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func (q *Queries) InsertAuditLog(ctx context.Context, arg db.InsertAuditLogParams) error {
+	_, err := q.db.ExecContext(ctx, insertAuditLog,
+		timestamp(arg.CreatedAt),
+		arg.Actor,
+		arg.Action,
+		arg.Resource,
+		intArray(arg.RecordIds),
+		arg.Subject,
+		arg.ClientIp,
+		arg.RequestID,
+		arg.Filter,
+		arg.Records,
+	)
+	return err
+}
+
+const selectAuditLog = `SELECT id, created_at, actor, action, resource, record_ids, subject, client_ip, request_id, filter, records FROM audit_log
+WHERE (?1 IS NULL
+    OR created_at >= ?1)
+AND (?2 IS NULL
+    OR created_at < ?2)
+AND (?3 IS NULL
+    OR actor = ?3)
+AND (?4 IS NULL
+    OR action = ?4)
+AND (?5 IS NULL
+    OR resource = ?5)
+AND (?6 IS NULL
+    OR EXISTS (
+        SELECT
+            1
+        FROM
+            json_each(audit_log.record_ids)
+        WHERE
+            value = ?6))
+AND (?7 IS NULL
+    OR id < ?7)
+ORDER BY
+    id DESC
+LIMIT ?8`
+
+func (q *Queries) SelectAuditLog(ctx context.Context, arg db.SelectAuditLogParams) ([]db.AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, selectAuditLog,
+		timestamp(arg.CreatedAfter),
+		timestamp(arg.CreatedBefore),
+		arg.Actor,
+		arg.Action,
+		arg.Resource,
+		arg.RecordID,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []db.AuditLog
+	for rows.Next() {
+		var i db.AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			scanTimestamp{&i.CreatedAt},
+			&i.Actor,
+			&i.Action,
+			&i.Resource,
+			scanIntArray{&i.RecordIds},
+			&i.Subject,
+			&i.ClientIp,
+			&i.RequestID,
+			&i.Filter,
+			&i.Records,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
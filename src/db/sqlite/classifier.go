@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/sevaho/goforms/src/db"
+)
+
+const selectClassifierTokens = `SELECT token, spam, ham FROM classifier_tokens WHERE token IN (SELECT value FROM json_each(?))`
+
+func (q *Queries) SelectClassifierTokens(ctx context.Context, tokens []string) ([]db.ClassifierToken, error) {
+	rows, err := q.db.QueryContext(ctx, selectClassifierTokens, textArray(tokens))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []db.ClassifierToken
+	for rows.Next() {
+		var i db.ClassifierToken
+		if err := rows.Scan(&i.Token, &i.Spam, &i.Ham); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const selectClassifierDocuments = `SELECT label, documents FROM classifier_documents`
+
+func (q *Queries) SelectClassifierDocuments(ctx context.Context) ([]db.ClassifierDocument, error) {
+	rows, err := q.db.QueryContext(ctx, selectClassifierDocuments)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []db.ClassifierDocument
+	for rows.Next() {
+		var i db.ClassifierDocument
+		if err := rows.Scan(&i.Label, &i.Documents); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+// The WHERE keeps SQLite from reading ON CONFLICT as a join constraint.
+const updateClassifierTokens = `INSERT INTO classifier_tokens (token, spam, ham)
+SELECT
+    value,
+    max(0, ?2),
+    max(0, ?3)
+FROM
+    json_each(?1)
+WHERE
+    TRUE
+ON CONFLICT (token)
+    DO UPDATE SET
+        spam = max(0, classifier_tokens.spam + ?2),
+        ham = max(0, classifier_tokens.ham + ?3)`
+
+func (q *Queries) UpdateClassifierTokens(ctx context.Context, arg db.UpdateClassifierTokensParams) error {
+	_, err := q.db.ExecContext(ctx, updateClassifierTokens, textArray(arg.Tokens), arg.Spam, arg.Ham)
+	return err
+}
+
+const updateClassifierDocuments = `INSERT INTO classifier_documents (label, documents)
+    VALUES (?1, max(0, ?2))
+ON CONFLICT (label)
+    DO UPDATE SET
+        documents = max(0, classifier_documents.documents + ?2)`
+
+func (q *Queries) UpdateClassifierDocuments(ctx context.Context, arg db.UpdateClassifierDocumentsParams) error {
+	_, err := q.db.ExecContext(ctx, updateClassifierDocuments, arg.Label, arg.Documents)
+	return err
+}
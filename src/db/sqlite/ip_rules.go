@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+)
+
+const ipRuleColumns = `id, created_at, cidr, action, comment, expires_at`
+
+func scanIPRule(row scanner) (db.IpRule, error) {
+	var i db.IpRule
+	err := row.Scan(
+		&i.ID,
+		scanTimestamp{&i.CreatedAt},
+		&i.Cidr,
+		&i.Action,
+		&i.Comment,
+		scanTimestamp{&i.ExpiresAt},
+	)
+	return i, noRows(err)
+}
+
+const insertIPRule = `INSERT INTO ip_rules (created_at, cidr, action, comment, expires_at)
+    VALUES (?, ?, ?, ?, ?)
+RETURNING
+    ` + ipRuleColumns
+
+func (q *Queries) InsertIPRule(ctx context.Context, arg db.InsertIPRuleParams) (db.IpRule, error) {
+	return scanIPRule(q.db.QueryRowContext(ctx, insertIPRule,
+		timestamp(arg.CreatedAt),
+		arg.Cidr,
+		arg.Action,
+		arg.Comment,
+		timestamp(arg.ExpiresAt),
+	))
+}
+
+const deleteIPRule = `DELETE FROM ip_rules WHERE id = ?`
+
+func (q *Queries) DeleteIPRule(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteIPRule, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const selectActiveIPRules = `SELECT ` + ipRuleColumns + ` FROM ip_rules
+WHERE
+    expires_at IS NULL
+    OR expires_at > ?
+ORDER BY
+    id`
+
+func (q *Queries) SelectActiveIPRules(ctx context.Context, now pgtype.Timestamp) ([]db.IpRule, error) {
+	rows, err := q.db.QueryContext(ctx, selectActiveIPRules, timestamp(now))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []db.IpRule
+	for rows.Next() {
+		i, err := scanIPRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+)
+
+const useCaptchaChallenge = `INSERT INTO captcha_challenges (id, expires_at)
+    VALUES (?, ?)
+ON CONFLICT (id)
+    DO NOTHING`
+
+func (q *Queries) UseCaptchaChallenge(ctx context.Context, arg db.UseCaptchaChallengeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useCaptchaChallenge, arg.ID, timestamp(arg.ExpiresAt))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpiredCaptchaChallenges = `DELETE FROM captcha_challenges WHERE expires_at < ?`
+
+func (q *Queries) DeleteExpiredCaptchaChallenges(ctx context.Context, expiresAt pgtype.Timestamp) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredCaptchaChallenges, timestamp(expiresAt))
+	return err
+}
+
+const deleteFullRateLimits = `DELETE FROM rate_limits WHERE updated_at < ?`
+
+func (q *Queries) DeleteFullRateLimits(ctx context.Context, updatedAt pgtype.Timestamp) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFullRateLimits, timestamp(updatedAt))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Refills the bucket for the time passed since the last request, then takes a
+// token if there is one. ?1 is the bucket, ?2 the capacity, ?3 now and ?4 the
+// refill per second.
+const takeRateLimitToken = `INSERT INTO rate_limits (bucket, tokens, allowed, updated_at)
+    VALUES (?1, ?2 - 1, TRUE, ?3)
+ON CONFLICT (bucket)
+    DO UPDATE SET
+        tokens = CASE WHEN min(?2, rate_limits.tokens + max(0, (julianday(?3) - julianday(rate_limits.updated_at)) * 86400) * ?4) >= 1 THEN
+            min(?2, rate_limits.tokens + max(0, (julianday(?3) - julianday(rate_limits.updated_at)) * 86400) * ?4) - 1
+        ELSE
+            min(?2, rate_limits.tokens + max(0, (julianday(?3) - julianday(rate_limits.updated_at)) * 86400) * ?4)
+        END,
+        allowed = min(?2, rate_limits.tokens + max(0, (julianday(?3) - julianday(rate_limits.updated_at)) * 86400) * ?4) >= 1,
+        updated_at = ?3
+    RETURNING
+        tokens,
+        allowed`
+
+func (q *Queries) TakeRateLimitToken(ctx context.Context, arg db.TakeRateLimitTokenParams) (db.TakeRateLimitTokenRow, error) {
+	var i db.TakeRateLimitTokenRow
+	err := q.db.QueryRowContext(ctx, takeRateLimitToken, arg.Bucket, arg.Capacity, timestamp(arg.Now), arg.RefillPerSecond).Scan(&i.Tokens, &i.Allowed)
+	return i, err
+}
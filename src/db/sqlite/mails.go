@@ -0,0 +1,379 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+)
+
+const mailColumns = `id, created_at, mail_provider, success, mail_from, recipients, subject, content, error, form_id, spam, spam_score, spam_reasons, released_at, classified_as, fingerprint, idempotency_key, duplicates, last_duplicate_at, captcha_score, submission_id, client_ip, user_agent, origin, language, country, request_id, blind_index`
+
+func scanMail(row scanner) (db.Mail, error) {
+	var i db.Mail
+	err := row.Scan(
+		&i.ID,
+		scanTimestamp{&i.CreatedAt},
+		&i.MailProvider,
+		&i.Success,
+		&i.MailFrom,
+		scanTextArray{&i.Recipients},
+		&i.Subject,
+		&i.Content,
+		&i.Error,
+		&i.FormID,
+		&i.Spam,
+		&i.SpamScore,
+		scanTextArray{&i.SpamReasons},
+		scanTimestamp{&i.ReleasedAt},
+		&i.ClassifiedAs,
+		&i.Fingerprint,
+		&i.IdempotencyKey,
+		&i.Duplicates,
+		scanTimestamp{&i.LastDuplicateAt},
+		&i.CaptchaScore,
+		&i.SubmissionID,
+		&i.ClientIp,
+		&i.UserAgent,
+		&i.Origin,
+		&i.Language,
+		&i.Country,
+		&i.RequestID,
+		scanTextArray{&i.BlindIndex},
+	)
+	return i, noRows(err)
+}
+
+func scanMails(rows *sql.Rows, err error) ([]db.Mail, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []db.Mail
+	for rows.Next() {
+		i, err := scanMail(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const insertMail = `INSERT INTO mails (created_at, mail_provider, mail_from, success, recipients, subject, content, error, form_id, spam, spam_score, spam_reasons, fingerprint, idempotency_key, captcha_score, submission_id, client_ip, user_agent, origin, language, country, request_id, blind_index)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING
+    id`
+
+func (q *Queries) InsertMail(ctx context.Context, arg db.InsertMailParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, insertMail,
+		timestamp(arg.CreatedAt),
+		arg.MailProvider,
+		arg.MailFrom,
+		arg.Success,
+		textArray(arg.Recipients),
+		arg.Subject,
+		arg.Content,
+		arg.Error,
+		arg.FormID,
+		arg.Spam,
+		arg.SpamScore,
+		textArray(arg.SpamReasons),
+		arg.Fingerprint,
+		arg.IdempotencyKey,
+		arg.CaptchaScore,
+		arg.SubmissionID,
+		arg.ClientIp,
+		arg.UserAgent,
+		arg.Origin,
+		arg.Language,
+		arg.Country,
+		arg.RequestID,
+		textArray(arg.BlindIndex),
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, uniqueViolation(err)
+}
+
+const selectMailByID = `SELECT ` + mailColumns + ` FROM mails WHERE id = ?`
+
+func (q *Queries) SelectMailByID(ctx context.Context, id int32) (db.Mail, error) {
+	return scanMail(q.db.QueryRowContext(ctx, selectMailByID, id))
+}
+
+const selectAllMails = `SELECT ` + mailColumns + ` FROM mails
+WHERE
+    spam = ?
+ORDER BY
+    created_at DESC
+LIMIT ? OFFSET ?`
+
+func (q *Queries) SelectAllMails(ctx context.Context, arg db.SelectAllMailsParams) ([]db.Mail, error) {
+	return scanMails(q.db.QueryContext(ctx, selectAllMails, arg.Spam, arg.Limit, arg.Offset))
+}
+
+const countAllMails = `SELECT count(*) FROM mails WHERE spam = ?`
+
+func (q *Queries) CountAllMails(ctx context.Context, spam bool) (int64, error) {
+	var count int64
+	err := q.db.QueryRowContext(ctx, countAllMails, spam).Scan(&count)
+	return count, err
+}
+
+// Filters that are NULL match every mail, like the Postgres query.
+const searchMailsWhere = `
+WHERE (?1 IS NULL
+    OR created_at >= ?1)
+AND (?2 IS NULL
+    OR created_at < ?2)
+AND (?3 IS NULL
+    OR form_id = ?3)
+AND (?4 IS NULL
+    OR mail_provider = ?4)
+AND (?5 IS NULL
+    OR success = ?5)
+AND (?6 IS NULL
+    OR spam = ?6)
+AND (?7 IS NULL
+    OR EXISTS (
+        SELECT
+            1
+        FROM
+            json_each(mails.blind_index)
+        WHERE
+            value = ?7))`
+
+const searchMails = `SELECT ` + mailColumns + ` FROM mails` + searchMailsWhere + `
+AND (?8 IS NULL
+    OR (created_at, id) < (?8, ?9))
+ORDER BY
+    created_at DESC,
+    id DESC
+LIMIT ?10 OFFSET ?11`
+
+func (q *Queries) SearchMails(ctx context.Context, arg db.SearchMailsParams) ([]db.Mail, error) {
+	return scanMails(q.db.QueryContext(ctx, searchMails,
+		timestamp(arg.CreatedAfter),
+		timestamp(arg.CreatedBefore),
+		arg.FormID,
+		arg.MailProvider,
+		arg.Success,
+		arg.Spam,
+		arg.BlindIndex,
+		timestamp(arg.CursorCreatedAt),
+		arg.CursorID,
+		arg.Limit,
+		arg.Offset,
+	))
+}
+
+const countSearchMails = `SELECT count(*) FROM mails` + searchMailsWhere
+
+func (q *Queries) CountSearchMails(ctx context.Context, arg db.CountSearchMailsParams) (int64, error) {
+	var count int64
+	err := q.db.QueryRowContext(ctx, countSearchMails,
+		timestamp(arg.CreatedAfter),
+		timestamp(arg.CreatedBefore),
+		arg.FormID,
+		arg.MailProvider,
+		arg.Success,
+		arg.Spam,
+		arg.BlindIndex,
+	).Scan(&count)
+	return count, err
+}
+
+const selectDuplicateMail = `SELECT ` + mailColumns + ` FROM mails
+WHERE
+    form_id = ?
+    AND fingerprint = ?
+    AND created_at > ?
+ORDER BY
+    created_at DESC
+LIMIT 1`
+
+func (q *Queries) SelectDuplicateMail(ctx context.Context, arg db.SelectDuplicateMailParams) (db.Mail, error) {
+	return scanMail(q.db.QueryRowContext(ctx, selectDuplicateMail, arg.FormID, arg.Fingerprint, timestamp(arg.CreatedAt)))
+}
+
+const selectMailByIdempotencyKey = `SELECT ` + mailColumns + ` FROM mails
+WHERE
+    form_id = ?
+    AND idempotency_key = ?
+ORDER BY
+    created_at DESC
+LIMIT 1`
+
+func (q *Queries) SelectMailByIdempotencyKey(ctx context.Context, arg db.SelectMailByIdempotencyKeyParams) (db.Mail, error) {
+	return scanMail(q.db.QueryRowContext(ctx, selectMailByIdempotencyKey, arg.FormID, arg.IdempotencyKey))
+}
+
+const incrementMailDuplicates = `UPDATE mails SET duplicates = duplicates + 1, last_duplicate_at = ? WHERE id = ?`
+
+func (q *Queries) IncrementMailDuplicates(ctx context.Context, arg db.IncrementMailDuplicatesParams) error {
+	_, err := q.db.ExecContext(ctx, incrementMailDuplicates, timestamp(arg.LastDuplicateAt), arg.ID)
+	return err
+}
+
+const releaseMail = `UPDATE mails SET spam = FALSE, released_at = ?, success = FALSE, error = NULL WHERE id = ? AND spam`
+
+func (q *Queries) ReleaseMail(ctx context.Context, arg db.ReleaseMailParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, releaseMail, timestamp(arg.ReleasedAt), arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const abandonMail = `UPDATE mails SET error = ?, idempotency_key = NULL, fingerprint = NULL
+WHERE id = ? AND NOT success AND NOT spam AND coalesce(error, '') = ''`
+
+func (q *Queries) AbandonMail(ctx context.Context, arg db.AbandonMailParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, abandonMail, arg.Error, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setMailResult = `UPDATE mails SET success = ?, error = ? WHERE id = ?`
+
+// Records the outcome of sending a mail that was stored before it was sent.
+func (q *Queries) SetMailResult(ctx context.Context, arg db.SetMailResultParams) error {
+	_, err := q.db.ExecContext(ctx, setMailResult, arg.Success, arg.Error, arg.ID)
+	return err
+}
+
+const setMailClassification = `UPDATE mails SET classified_as = ? WHERE id = ? AND classified_as IS ?`
+
+func (q *Queries) SetMailClassification(ctx context.Context, arg db.SetMailClassificationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setMailClassification, arg.ClassifiedAs, arg.ID, arg.PreviousClassifiedAs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const selectMailsAfterID = `SELECT ` + mailColumns + ` FROM mails WHERE id > ? ORDER BY id LIMIT ?`
+
+func (q *Queries) SelectMailsAfterID(ctx context.Context, arg db.SelectMailsAfterIDParams) ([]db.Mail, error) {
+	return scanMails(q.db.QueryContext(ctx, selectMailsAfterID, arg.AfterID, arg.Limit))
+}
+
+const updateMailCiphertexts = `UPDATE
+    mails
+SET
+    mail_from = ?,
+    recipients = ?,
+    subject = ?,
+    content = ?,
+    client_ip = ?,
+    user_agent = ?,
+    origin = ?
+WHERE
+    id = ?
+    AND content = ?`
+
+func (q *Queries) UpdateMailCiphertexts(ctx context.Context, arg db.UpdateMailCiphertextsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateMailCiphertexts,
+		arg.MailFrom,
+		textArray(arg.Recipients),
+		arg.Subject,
+		arg.Content,
+		arg.ClientIp,
+		arg.UserAgent,
+		arg.Origin,
+		arg.ID,
+		arg.PreviousContent,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateMailBlindIndex = `UPDATE mails SET blind_index = ? WHERE id = ?`
+
+func (q *Queries) UpdateMailBlindIndex(ctx context.Context, arg db.UpdateMailBlindIndexParams) error {
+	_, err := q.db.ExecContext(ctx, updateMailBlindIndex, textArray(arg.BlindIndex), arg.ID)
+	return err
+}
+
+const selectMailIDsBySubmissionID = `SELECT id FROM mails WHERE submission_id = ? ORDER BY id`
+
+func (q *Queries) SelectMailIDsBySubmissionID(ctx context.Context, submissionID pgtype.Int4) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, selectMailIDsBySubmissionID, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	return items, rows.Err()
+}
+
+const selectSubjectMails = `SELECT ` + mailColumns + ` FROM mails
+WHERE
+    EXISTS (
+        SELECT
+            1
+        FROM
+            json_each(mails.blind_index)
+        WHERE
+            value = ?)
+ORDER BY
+    id`
+
+func (q *Queries) SelectSubjectMails(ctx context.Context, hash string) ([]db.Mail, error) {
+	return scanMails(q.db.QueryContext(ctx, selectSubjectMails, hash))
+}
+
+const deleteMailsByID = `DELETE FROM mails WHERE id IN (SELECT value FROM json_each(?))`
+
+func (q *Queries) DeleteMailsByID(ctx context.Context, ids []int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteMailsByID, intArray(ids))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const shredMailsByID = `UPDATE
+    mails
+SET
+    mail_from = '',
+    recipients = '[]',
+    subject = '',
+    content = '',
+    client_ip = NULL,
+    user_agent = NULL,
+    origin = NULL,
+    fingerprint = NULL,
+    idempotency_key = NULL,
+    submission_id = NULL,
+    spam_reasons = '[]',
+    language = NULL,
+    country = NULL,
+    request_id = NULL,
+    blind_index = '[]'
+WHERE
+    id IN (SELECT value FROM json_each(?))`
+
+// Wipes everything personal of the mails, the rows stay for the statistics.
+func (q *Queries) ShredMailsByID(ctx context.Context, ids []int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, shredMailsByID, intArray(ids))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
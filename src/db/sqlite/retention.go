@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/sevaho/goforms/src/db"
+)
+
+// Rows expire when they are older than the cutoff of their form, ?1 holds the
+// cutoffs per form as [[form_id, cutoff], ...] and ?2 the default cutoff.
+func expired(table string) string {
+	return `created_at < COALESCE((
+        SELECT
+            json_extract(r.value, '$[1]')
+        FROM
+            json_each(?1) AS r
+        WHERE
+            json_extract(r.value, '$[0]') = ` + table + `.form_id), ?2)`
+}
+
+var countExpiredMails = `SELECT count(*) FROM mails WHERE ` + expired("mails")
+
+func (q *Queries) CountExpiredMails(ctx context.Context, arg db.CountExpiredMailsParams) (int64, error) {
+	var count int64
+	err := q.db.QueryRowContext(ctx, countExpiredMails, formCutoffs(arg.FormIds, arg.Cutoffs), timestamp(arg.DefaultCutoff)).Scan(&count)
+	return count, err
+}
+
+var insertExpiredMailStatistics = `INSERT INTO mail_statistics (day, form_id, spam, success, mails)
+SELECT
+    date(created_at),
+    COALESCE(form_id, '00000000-0000-0000-0000-000000000000'),
+    spam,
+    success,
+    count(*)
+FROM
+    mails
+WHERE
+    ` + expired("mails") + `
+GROUP BY
+    1,
+    2,
+    3,
+    4
+ON CONFLICT (day,
+    form_id,
+    spam,
+    success)
+    DO UPDATE SET
+        mails = mail_statistics.mails + excluded.mails`
+
+var deleteExpiredMails = `DELETE FROM mails WHERE ` + expired("mails")
+
+// SQLite can not delete in a CTE, the statistics are added in the same
+// transaction instead.
+func (q *Queries) DeleteExpiredMails(ctx context.Context, arg db.DeleteExpiredMailsParams) (int64, error) {
+	var count int64
+	cutoffs, defaultCutoff := formCutoffs(arg.FormIds, arg.Cutoffs), timestamp(arg.DefaultCutoff)
+
+	err := q.withTx(ctx, func(tx dbtx) error {
+		if arg.KeepStatistics {
+			if _, err := tx.ExecContext(ctx, insertExpiredMailStatistics, cutoffs, defaultCutoff); err != nil {
+				return err
+			}
+		}
+
+		result, err := tx.ExecContext(ctx, deleteExpiredMails, cutoffs, defaultCutoff)
+		if err != nil {
+			return err
+		}
+		count, err = result.RowsAffected()
+		return err
+	})
+	return count, err
+}
+
+var countExpiredSubmissions = `SELECT count(*) FROM submissions WHERE ` + expired("submissions")
+
+func (q *Queries) CountExpiredSubmissions(ctx context.Context, arg db.CountExpiredSubmissionsParams) (int64, error) {
+	var count int64
+	err := q.db.QueryRowContext(ctx, countExpiredSubmissions, formCutoffs(arg.FormIds, arg.Cutoffs), timestamp(arg.DefaultCutoff)).Scan(&count)
+	return count, err
+}
+
+var deleteExpiredSubmissions = `DELETE FROM submissions WHERE ` + expired("submissions")
+
+func (q *Queries) DeleteExpiredSubmissions(ctx context.Context, arg db.DeleteExpiredSubmissionsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredSubmissions, formCutoffs(arg.FormIds, arg.Cutoffs), timestamp(arg.DefaultCutoff))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeRunColumns = `id, started_at, finished_at, dry_run, mails, submissions, error`
+
+func scanPurgeRun(row scanner) (db.PurgeRun, error) {
+	var i db.PurgeRun
+	err := row.Scan(
+		&i.ID,
+		scanTimestamp{&i.StartedAt},
+		scanTimestamp{&i.FinishedAt},
+		&i.DryRun,
+		&i.Mails,
+		&i.Submissions,
+		&i.Error,
+	)
+	return i, noRows(err)
+}
+
+const insertPurgeRun = `INSERT INTO purge_runs (started_at, finished_at, dry_run, mails, submissions, error)
+    VALUES (?, ?, ?, ?, ?, ?)
+RETURNING
+    ` + purgeRunColumns
+
+func (q *Queries) InsertPurgeRun(ctx context.Context, arg db.InsertPurgeRunParams) (db.PurgeRun, error) {
+	return scanPurgeRun(q.db.QueryRowContext(ctx, insertPurgeRun,
+		timestamp(arg.StartedAt),
+		timestamp(arg.FinishedAt),
+		arg.DryRun,
+		arg.Mails,
+		arg.Submissions,
+		arg.Error,
+	))
+}
+
+const selectPurgeRuns = `SELECT ` + purgeRunColumns + ` FROM purge_runs ORDER BY id DESC LIMIT ?`
+
+func (q *Queries) SelectPurgeRuns(ctx context.Context, limit int32) ([]db.PurgeRun, error) {
+	rows, err := q.db.QueryContext(ctx, selectPurgeRuns, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []db.PurgeRun
+	for rows.Next() {
+		i, err := scanPurgeRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+// SQLite has no advisory locks, writers are serialized by the database lock.
+func (q *Queries) TryAdvisoryXactLock(ctx context.Context, key int64) (bool, error) {
+	return true, nil
+}
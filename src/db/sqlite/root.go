@@ -0,0 +1,241 @@
+// Package sqlite stores goforms in SQLite for sites that are too small to run
+// Postgres for. It implements db.Querier with the same behaviour as the queries
+// sqlc generates for Postgres, arrays are stored as JSON and timestamps as text
+// that sorts in time order.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/mattn/go-sqlite3"
+	"github.com/sevaho/goforms/src/db"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+// The queries run on the connection or in a transaction of it.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Queries struct {
+	db dbtx
+	// nil in a transaction
+	conn *sql.DB
+}
+
+var _ db.Store = (*Queries)(nil)
+
+// The layout go-sqlite3 writes time.Time in, timestamps are always UTC so the
+// text sorts like the time.
+const timeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// Whether the DSN is for SQLite, eg. sqlite:goforms.sqlite3 or
+// sqlite:///var/lib/goforms/goforms.sqlite3 like dbmate expects.
+func IsDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "sqlite:") || strings.HasPrefix(dsn, "sqlite3:")
+}
+
+// Turns the dbmate style DSN into a go-sqlite3 one, foreign keys are on as
+// deleting a submission unlinks its mails.
+func connectionString(dsn string) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", err
+	}
+
+	path := u.Opaque
+	if path == "" {
+		path = u.Host + u.Path
+		if u.Host != "" {
+			path = "/" + path
+		}
+	}
+	if path == "" {
+		return "", errors.New("No database file in " + dsn)
+	}
+
+	query := u.Query()
+	for key, value := range map[string]string{"_foreign_keys": "1", "_busy_timeout": "5000", "_journal_mode": "WAL"} {
+		if !query.Has(key) {
+			query.Set(key, value)
+		}
+	}
+
+	return "file:" + path + "?" + query.Encode(), nil
+}
+
+func NewDB(dsn string) *Queries {
+	connection, err := connectionString(dsn)
+	if err != nil {
+		panic(err)
+	}
+
+	conn, err := sql.Open("sqlite3", connection)
+	if err != nil {
+		panic(err)
+	}
+
+	// SQLite has a single writer, one connection keeps the writes from failing
+	// with "database is locked".
+	conn.SetMaxOpenConns(1)
+
+	if err := conn.Ping(); err != nil {
+		panic(fmt.Sprintf("Unable to open db %s: %s", dsn, err))
+	}
+
+	logger.Logger.Info().Msgf("[DB] opened %s", dsn)
+	return &Queries{db: conn, conn: conn}
+}
+
+// The Postgres queries return pgx.ErrNoRows, the repository checks for it.
+func noRows(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgx.ErrNoRows
+	}
+	return err
+}
+
+// Postgres reports a unique violation with code 23505, the repository checks
+// for it.
+func uniqueViolation(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return &pgconn.PgError{Code: "23505", Message: sqliteErr.Error()}
+	}
+	return err
+}
+
+// The retention uses -infinity as "never expires", it becomes the smallest time.
+func timestamp(ts pgtype.Timestamp) any {
+	if !ts.Valid {
+		return nil
+	}
+	switch ts.InfinityModifier {
+	case pgtype.NegativeInfinity:
+		return time.Time{}.Format(timeFormat)
+	case pgtype.Infinity:
+		return time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC).Format(timeFormat)
+	}
+	return ts.Time.UTC().Format(timeFormat)
+}
+
+// Scans text or a time.Time into a timestamp, go-sqlite3 only parses columns
+// that are declared as timestamp.
+type scanTimestamp struct {
+	dst *pgtype.Timestamp
+}
+
+func (s scanTimestamp) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		*s.dst = pgtype.Timestamp{}
+	case time.Time:
+		*s.dst = pgtype.Timestamp{Time: src.UTC(), Valid: true}
+	case string:
+		t, err := time.Parse(timeFormat, src)
+		if err != nil {
+			return err
+		}
+		*s.dst = pgtype.Timestamp{Time: t.UTC(), Valid: true}
+	default:
+		return fmt.Errorf("cannot scan %T into a timestamp", src)
+	}
+	return nil
+}
+
+// Arrays are stored as JSON so json_each can search them.
+func textArray(values []string) string {
+	if values == nil {
+		return "[]"
+	}
+	b, _ := json.Marshal(values)
+	return string(b)
+}
+
+func intArray(values []int32) string {
+	if values == nil {
+		return "[]"
+	}
+	b, _ := json.Marshal(values)
+	return string(b)
+}
+
+type scanTextArray struct {
+	dst *[]string
+}
+
+func (s scanTextArray) Scan(src any) error {
+	switch src := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(src), s.dst)
+	case []byte:
+		return json.Unmarshal(src, s.dst)
+	}
+	return fmt.Errorf("cannot scan %T into an array", src)
+}
+
+type scanIntArray struct {
+	dst *[]int32
+}
+
+func (s scanIntArray) Scan(src any) error {
+	switch src := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(src), s.dst)
+	case []byte:
+		return json.Unmarshal(src, s.dst)
+	}
+	return fmt.Errorf("cannot scan %T into an array", src)
+}
+
+// The cutoffs of the retention per form as [[form_id, cutoff], ...].
+func formCutoffs(formIDs []pgtype.UUID, cutoffs []pgtype.Timestamp) string {
+	pairs := make([][2]any, 0, len(formIDs))
+	for i, formID := range formIDs {
+		if i < len(cutoffs) {
+			pairs = append(pairs, [2]any{formID.String(), timestamp(cutoffs[i])})
+		}
+	}
+	b, _ := json.Marshal(pairs)
+	return string(b)
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// Runs f in a transaction, or in the transaction the queries are already in.
+func (q *Queries) withTx(ctx context.Context, f func(tx dbtx) error) error {
+	if q.conn == nil {
+		return f(q.db)
+	}
+
+	tx, err := q.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := f(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (q *Queries) InTx(ctx context.Context, f func(q db.Querier) error) error {
+	return q.withTx(ctx, func(tx dbtx) error {
+		return f(&Queries{db: tx})
+	})
+}
@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+)
+
+// date_trunc for the buckets of the statistics, weeks start on monday like in
+// Postgres. The result is in the layout of the stored timestamps.
+func dateTrunc(column string) string {
+	return `(CASE ?1
+    WHEN 'hour' THEN strftime('%Y-%m-%d %H:00:00', ` + column + `)
+    WHEN 'day' THEN date(` + column + `) || ' 00:00:00'
+    WHEN 'week' THEN date(` + column + `, '-6 days', 'weekday 1') || ' 00:00:00'
+    WHEN 'month' THEN strftime('%Y-%m-01 00:00:00', ` + column + `)
+END || '+00:00')`
+}
+
+var countMailsByBucket = `SELECT
+    ` + dateTrunc("created_at") + ` AS bucket,
+    form_id,
+    mail_provider,
+    count(*) AS mails,
+    sum(spam) AS spam,
+    sum(success) AS success
+FROM
+    mails
+WHERE
+    created_at >= ?2
+    AND created_at < ?3
+GROUP BY
+    1,
+    2,
+    3
+ORDER BY
+    1,
+    2,
+    3`
+
+func (q *Queries) CountMailsByBucket(ctx context.Context, arg db.CountMailsByBucketParams) ([]db.CountMailsByBucketRow, error) {
+	rows, err := q.db.QueryContext(ctx, countMailsByBucket, arg.Bucket, timestamp(arg.CreatedAfter), timestamp(arg.CreatedBefore))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []db.CountMailsByBucketRow
+	for rows.Next() {
+		var i db.CountMailsByBucketRow
+		if err := rows.Scan(
+			scanTimestamp{&i.Bucket},
+			&i.FormID,
+			&i.MailProvider,
+			&i.Mails,
+			&i.Spam,
+			&i.Success,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+var countPurgedMailsByBucket = `SELECT
+    ` + dateTrunc("day") + ` AS bucket,
+    form_id,
+    sum(mails) AS mails,
+    sum(CASE WHEN spam THEN mails ELSE 0 END) AS spam,
+    sum(CASE WHEN success THEN mails ELSE 0 END) AS success
+FROM
+    mail_statistics
+WHERE
+    day || ' 00:00:00+00:00' >= ?2
+    AND day || ' 00:00:00+00:00' < ?3
+GROUP BY
+    1,
+    2
+ORDER BY
+    1,
+    2`
+
+func (q *Queries) CountPurgedMailsByBucket(ctx context.Context, arg db.CountPurgedMailsByBucketParams) ([]db.CountPurgedMailsByBucketRow, error) {
+	rows, err := q.db.QueryContext(ctx, countPurgedMailsByBucket, arg.Bucket, timestamp(arg.CreatedAfter), timestamp(arg.CreatedBefore))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []db.CountPurgedMailsByBucketRow
+	for rows.Next() {
+		var i db.CountPurgedMailsByBucketRow
+		if err := rows.Scan(
+			scanTimestamp{&i.Bucket},
+			&i.FormID,
+			&i.Mails,
+			&i.Spam,
+			&i.Success,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const selectMailOrigins = `SELECT origin FROM mails WHERE created_at >= ? AND created_at < ? AND origin IS NOT NULL`
+
+func (q *Queries) SelectMailOrigins(ctx context.Context, arg db.SelectMailOriginsParams) ([]pgtype.Text, error) {
+	rows, err := q.db.QueryContext(ctx, selectMailOrigins, timestamp(arg.CreatedAfter), timestamp(arg.CreatedBefore))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []pgtype.Text
+	for rows.Next() {
+		var origin pgtype.Text
+		if err := rows.Scan(&origin); err != nil {
+			return nil, err
+		}
+		items = append(items, origin)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sevaho/goforms/src/db"
+)
+
+const submissionColumns = `id, created_at, form_id, fields`
+
+func scanSubmission(row scanner) (db.Submission, error) {
+	var i db.Submission
+	err := row.Scan(&i.ID, scanTimestamp{&i.CreatedAt}, &i.FormID, &i.Fields)
+	return i, noRows(err)
+}
+
+func scanSubmissions(rows *sql.Rows, err error) ([]db.Submission, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []db.Submission
+	for rows.Next() {
+		i, err := scanSubmission(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const insertSubmission = `INSERT INTO submissions (created_at, form_id, fields) VALUES (?, ?, ?) RETURNING id`
+
+func (q *Queries) InsertSubmission(ctx context.Context, arg db.InsertSubmissionParams) (int32, error) {
+	var id int32
+	err := q.db.QueryRowContext(ctx, insertSubmission, timestamp(arg.CreatedAt), arg.FormID, arg.Fields).Scan(&id)
+	return id, err
+}
+
+const selectSubmissionByID = `SELECT ` + submissionColumns + ` FROM submissions WHERE id = ?`
+
+func (q *Queries) SelectSubmissionByID(ctx context.Context, id int32) (db.Submission, error) {
+	return scanSubmission(q.db.QueryRowContext(ctx, selectSubmissionByID, id))
+}
+
+const selectSubmissionsByID = `SELECT ` + submissionColumns + ` FROM submissions WHERE id IN (SELECT value FROM json_each(?))`
+
+func (q *Queries) SelectSubmissionsByID(ctx context.Context, ids []int32) ([]db.Submission, error) {
+	return scanSubmissions(q.db.QueryContext(ctx, selectSubmissionsByID, intArray(ids)))
+}
+
+const selectSubmissionsAfterID = `SELECT ` + submissionColumns + ` FROM submissions WHERE id > ? ORDER BY id LIMIT ?`
+
+func (q *Queries) SelectSubmissionsAfterID(ctx context.Context, arg db.SelectSubmissionsAfterIDParams) ([]db.Submission, error) {
+	return scanSubmissions(q.db.QueryContext(ctx, selectSubmissionsAfterID, arg.AfterID, arg.Limit))
+}
+
+const updateSubmissionFields = `UPDATE submissions SET fields = ? WHERE id = ? AND fields = ?`
+
+func (q *Queries) UpdateSubmissionFields(ctx context.Context, arg db.UpdateSubmissionFieldsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateSubmissionFields, arg.Fields, arg.ID, arg.PreviousFields)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSubmissionsByID = `DELETE FROM submissions WHERE id IN (SELECT value FROM json_each(?))`
+
+func (q *Queries) DeleteSubmissionsByID(ctx context.Context, ids []int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSubmissionsByID, intArray(ids))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const insertSubjectRequest = `INSERT INTO subject_requests (created_at, action, subject, mails, submissions) VALUES (?, ?, ?, ?, ?)`
+
+func (q *Queries) InsertSubjectRequest(ctx context.Context, arg db.InsertSubjectRequestParams) error {
+	_, err := q.db.ExecContext(ctx, insertSubjectRequest, timestamp(arg.CreatedAt), arg.Action, arg.Subject, arg.Mails, arg.Submissions)
+	return err
+}
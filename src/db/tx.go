@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Store is a Querier that can also run queries in a transaction, every
+// backend implements it.
+type Store interface {
+	Querier
+	// Runs f in a transaction that is committed when f returns nil and rolled
+	// back otherwise. Only the Querier given to f is in the transaction.
+	InTx(ctx context.Context, f func(q Querier) error) error
+}
+
+var _ Store = (*Queries)(nil)
+
+// Both the pool and a transaction can begin one, in a transaction it is a
+// savepoint.
+type beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+func (q *Queries) InTx(ctx context.Context, f func(q Querier) error) error {
+	conn, ok := q.db.(beginner)
+	if !ok {
+		return errors.New("The database connection can not begin a transaction")
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := f(q.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -2,23 +2,32 @@ package app
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"fmt"
 	"io/fs"
+	"net"
 	"os"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/sevaho/goforms/src/config"
 	database "github.com/sevaho/goforms/src/db"
+	"github.com/sevaho/goforms/src/db/memory"
+	"github.com/sevaho/goforms/src/db/sqlite"
 	"github.com/sevaho/goforms/src/internal/models"
 	"github.com/sevaho/goforms/src/internal/repository"
 	"github.com/sevaho/goforms/src/mailproviders"
 	"github.com/sevaho/goforms/src/mailproviders/fake"
 	"github.com/sevaho/goforms/src/mailproviders/mailersend"
 	"github.com/sevaho/goforms/src/pkg/logger"
-	"github.com/sevaho/goforms/src/pkg/recaptcha"
+	"github.com/sevaho/goforms/src/pkg/captcha"
+	"github.com/sevaho/goforms/src/pkg/encryption"
 	"github.com/sevaho/goforms/src/pkg/renderer"
+	"github.com/sevaho/goforms/src/pkg/spam"
 	"github.com/sevaho/goforms/src/pkg/telegram"
 	"github.com/sevaho/goforms/src/assets"
 	"github.com/unrolled/secure"
@@ -36,11 +45,17 @@ type App struct {
 	// Need to put it behind a mailer service that proxies other mta's as well
 	mailproviders *mailproviders.MailProviders
 	formsConfig   models.FormsConfig
+	spamEngines   map[uuid.UUID]*spam.Engine
+	captchas      map[uuid.UUID]captcha.Verifier
 	renderer      *renderer.RenderEngine
 	config        *config.Config
 	repository    *repository.Repository
-	db            database.Querier
+	db            database.Store
 	tx            *pgx.Tx
+	retention     models.Retention
+	stopRetention context.CancelFunc
+	// Buckets unused for this long are full and pruned by the retention job
+	rateLimitRefillTime time.Duration
 }
 
 func (app *App) logErrorFunc(c echo.Context, err error, stack []byte) error {
@@ -51,6 +66,84 @@ func (app *App) logErrorFunc(c echo.Context, err error, stack []byte) error {
 	return err
 }
 
+// Forms without captcha settings use reCAPTCHA with the keys from the environment.
+func defaultCaptcha(config *config.Config) models.Captcha {
+	return models.Captcha{
+		Provider: captcha.Recaptcha.Name,
+		SiteKey:  config.GOOGLE_RECAPTCHA_SITE_KEY,
+		Secret:   config.GOOGLE_RECAPTCHA_SECRET_KEY,
+	}
+}
+
+// Forms without rate limit settings use the one from the environment.
+func defaultRateLimit(config *config.Config) models.RateLimit {
+	return models.RateLimit{Burst: config.RATE_LIMIT_BURST, PerMinute: config.RATE_LIMIT_PER_MINUTE}
+}
+
+// Key that signs the proof-of-work challenges.
+func captchaKey(config *config.Config) []byte {
+	mac := hmac.New(sha256.New, []byte(config.SECRET_KEY))
+	mac.Write([]byte("goforms captcha key"))
+	return mac.Sum(nil)
+}
+
+// The scheme of DB_DSN picks the backend, memory:// keeps everything in memory,
+// sqlite: opens an SQLite file and anything else is Postgres. Only Postgres can
+// run in a transaction.
+func openDatabase(config *config.Config, withTransaction bool) (database.Store, *pgx.Tx) {
+	switch {
+	case memory.IsDSN(config.DB_DSN):
+		return memory.New(), nil
+	case sqlite.IsDSN(config.DB_DSN):
+		return sqlite.NewDB(config.DB_DSN), nil
+	}
+	return database.NewDB(config.DB_DSN, withTransaction)
+}
+
+// Encrypts with SECRET_KEY, the old keys are only used to decrypt.
+func newRepository(db database.Store, config *config.Config) *repository.Repository {
+	var old []encryption.Key
+	for id, secret := range config.OLD_SECRET_KEYS {
+		old = append(old, encryption.Key{ID: id, Secret: secret})
+	}
+
+	encryptor, err := encryption.NewEncryptor(encryption.Key{ID: config.SECRET_KEY_ID, Secret: config.SECRET_KEY}, old...)
+	if err != nil {
+		panic("Failed to initialize encryptor: " + err.Error())
+	}
+
+	// Once SECRET_KEY is rotated it is no longer the key the hashes were made
+	// with, falling back to it would silently break every lookup.
+	hashKey := config.HASH_KEY
+	if hashKey == "" && len(config.OLD_SECRET_KEYS) > 0 {
+		panic("HASH_KEY is required once OLD_SECRET_KEYS is set, set it to the SECRET_KEY the hashes were made with")
+	}
+	if hashKey == "" {
+		hashKey = config.SECRET_KEY
+	}
+
+	return repository.New(db, encryptor, hashKey)
+}
+
+// Client IP used for rate limiting, the blocklist and the metadata. Headers
+// are only trusted when the request comes from one of the trusted proxies,
+// anyone else could simply send their own X-Forwarded-For.
+func ipExtractor(trustedProxies []string) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range trustedProxies {
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			panic("Failed to parse TRUSTED_PROXIES: " + err.Error())
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
 // TODO:  <03-05-25, Sebastiaan Van Hoecke> // This should return a pointer to echo
 func New(config *config.Config) *App {
 	server := echo.New()
@@ -64,6 +157,7 @@ func New(config *config.Config) *App {
 	}
 	server.HideBanner = true
 	server.HidePort = true
+	server.IPExtractor = ipExtractor(config.TRUSTED_PROXIES)
 
 	// * * * * * * * * * * * * * * * * * *
 	// RENDER ENGINE
@@ -75,7 +169,8 @@ func New(config *config.Config) *App {
 	// DEPENDENCIES
 	// * * * * * * * * * * * * * * * * * *
 
-	db, tx := database.NewDB(config.DB_DSN, config.RUN_IN_TRANSACTION)
+	db, tx := openDatabase(config, config.RUN_IN_TRANSACTION)
+	formsConfig := loadFormConfig(config)
 
 	app := App{
 		server:   server,
@@ -85,17 +180,16 @@ func New(config *config.Config) *App {
 			fake.New(),
 		),
 		telegram:    telegram.New(config.TELEGRAM_BOT_API_KEY, config.TELEGRAM_BOT_CHAT_ID),
-		formsConfig: loadFormConfig(config),
+		formsConfig: formsConfig,
+		spamEngines: loadSpamEngines(formsConfig),
+		retention:   loadRetention(formsConfig, config),
 		db:          db,
 		tx:          tx,
-		repository:  repository.New(db, config.SECRET_KEY),
+		repository:  newRepository(db, config),
 		config:      config,
 	}
-
-	// * * * * * * * * * * * * * * * * * *
-	// LIBS
-	// * * * * * * * * * * * * * * * * * *
-	recaptcha.Init(config.GOOGLE_RECAPTCHA_SECRET_KEY)
+	app.rateLimitRefillTime = loadRateLimitRefillTime(formsConfig, defaultRateLimit(config))
+	app.captchas = loadCaptchaVerifiers(formsConfig, defaultCaptcha(config), captchaKey(config), app.repository)
 
 	// * * * * * * * * * * * * * * * * * *
 	// MIDDLEWARE
@@ -121,6 +215,12 @@ func New(config *config.Config) *App {
 }
 
 func (app *App) Serve(port int) {
+	if app.retention.Enabled() || app.rateLimitRefillTime > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		app.stopRetention = cancel
+		go app.runRetentionJob(ctx, app.retention, app.rateLimitRefillTime, app.config.RETENTION_INTERVAL)
+	}
+
 	go func() {
 		logger.Logger.Info().Msgf("[HTTP SERVER] Running on http://localhost:%d", port)
 		err := app.server.Start(fmt.Sprint(":", port))
@@ -131,6 +231,9 @@ func (app *App) Serve(port int) {
 }
 
 func (app *App) ShutDown(ctx context.Context) {
+	if app.stopRetention != nil {
+		app.stopRetention()
+	}
 
 	if app.tx != nil {
 		transaction := *app.tx
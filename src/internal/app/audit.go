@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"maps"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/internal/repository"
+)
+
+// The API key middlewares store who made the request under this key.
+const auditActorKey = "auditActor"
+
+// Records in the audit log that the API key of the request accessed the
+// records, email is the address of a data subject request. Records are only
+// returned once they are in the audit log.
+func audit(c echo.Context, repository *repository.Repository, action string, resource string, ids []int32, email string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	entry := auditEntry(c, action, resource)
+	entry.RecordIDs = ids
+
+	// Streamed records may have reached the client before it disconnected
+	return repository.Audit(context.WithoutCancel(c.Request().Context()), entry, email)
+}
+
+// Records in the audit log that the API key of the request accessed every
+// record matching a query, before any of them are returned. The email address
+// and field value that were searched for are left out of the query, the email
+// address is stored as its keyed hash. So is the API key the admin pages pass
+// in the query.
+func auditQuery(c echo.Context, repository *repository.Repository, action string, resource string, records int, email string) error {
+	// A copy, the handler still reads the query of the request
+	query := maps.Clone(c.QueryParams())
+	query.Del("email")
+	query.Del("value")
+	query.Del("apiKey")
+
+	entry := auditEntry(c, action, resource)
+	entry.Filter = query.Encode()
+	entry.Records = &records
+
+	return repository.Audit(c.Request().Context(), entry, email)
+}
+
+func auditEntry(c echo.Context, action string, resource string) models.AuditEntry {
+	actor, _ := c.Get(auditActorKey).(string)
+
+	return models.AuditEntry{
+		Actor:     actor,
+		Action:    action,
+		Resource:  resource,
+		ClientIP:  c.RealIP(),
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+	}
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sevaho/goforms/src/config"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+// Re-encrypts the stored mails and submissions with SECRET_KEY.
+func RotateKeys(ctx context.Context, batchSize int, config *config.Config) error {
+	db, _ := openDatabase(config, false)
+
+	result, err := newRepository(db, config).RotateKeys(ctx, batchSize)
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Info().Msgf("Rotated the keys of %d mails and %d submissions", result.Mails, result.Submissions)
+	return nil
+}
+
+// Recomputes the blind index of the stored mails with the searchable fields of the forms.
+func Reindex(ctx context.Context, batchSize int, config *config.Config) error {
+	db, _ := openDatabase(config, false)
+
+	searchable := map[uuid.UUID][]string{}
+	for _, form := range loadFormConfig(config).Forms {
+		searchable[form.ID] = form.Searchable
+	}
+
+	reindexed, err := newRepository(db, config).Reindex(ctx, batchSize, searchable)
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Info().Msgf("Reindexed %d mails", reindexed)
+	return nil
+}
+
+// Purges the expired mails once, a dry run only reports what would be purged.
+func Purge(ctx context.Context, dryRun bool, config *config.Config) error {
+	db, _ := openDatabase(config, false)
+
+	run, err := newRepository(db, config).Purge(ctx, loadRetention(loadFormConfig(config), config), dryRun)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		logger.Logger.Info().Msgf("Would purge %d mails and %d submissions", run.Mails, run.Submissions)
+	} else {
+		logger.Logger.Info().Msgf("Purged %d mails and %d submissions", run.Mails, run.Submissions)
+	}
+	return nil
+}
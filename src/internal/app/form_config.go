@@ -7,9 +7,12 @@ import (
 	"log"
 	"os"
 
+	"github.com/google/uuid"
 	"github.com/sevaho/goforms/src/config"
 	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/pkg/captcha"
 	"github.com/sevaho/goforms/src/pkg/logger"
+	"github.com/sevaho/goforms/src/pkg/spam"
 	"gopkg.in/yaml.v3"
 )
 
@@ -61,6 +64,9 @@ func loadFormConfig(config *config.Config) models.FormsConfig {
 	}
 
 	if fc.Check() {
+		if err := fc.Validate(); err != nil {
+			log.Fatal(err)
+		}
 		logger.Logger.Debug().Msgf("Loaded config: %v", fc)
 		return fc
 	}
@@ -71,3 +77,56 @@ func loadFormConfig(config *config.Config) models.FormsConfig {
 
 	panic("No formconfig given")
 }
+
+// Compiles the spam rules of every form together with the global spam rules.
+func loadSpamEngines(fc models.FormsConfig) map[uuid.UUID]*spam.Engine {
+	engines := make(map[uuid.UUID]*spam.Engine, len(fc.Forms))
+
+	for _, form := range fc.Forms {
+		engine, err := spam.New(fc.Spam, form.Spam)
+		if err != nil {
+			log.Fatalf("Invalid spam rules for form %s: %s", form.ID, err)
+		}
+		engines[form.ID] = engine
+	}
+
+	return engines
+}
+
+// Sets up the captcha verifier of every form, forms that skip the captcha have none.
+// Proof-of-work challenges are signed with key and replays are refused by store.
+func loadCaptchaVerifiers(
+	fc models.FormsConfig,
+	defaultCaptcha models.Captcha,
+	key []byte,
+	store captcha.ReplayStore,
+) map[uuid.UUID]captcha.Verifier {
+	verifiers := make(map[uuid.UUID]captcha.Verifier, len(fc.Forms))
+
+	for _, form := range fc.Forms {
+		if form.Skipcaptcha {
+			continue
+		}
+
+		c := form.GetCaptcha(defaultCaptcha)
+		if c.Provider == captcha.ProofOfWorkProvider.Name {
+			verifiers[form.ID] = captcha.NewProofOfWork(key, form.ID.String(), c.Difficulty, store)
+			continue
+		}
+
+		provider, ok := captcha.Providers[c.Provider]
+		if !ok {
+			log.Fatalf("Unknown captcha provider for form %s: %s", form.ID, c.Provider)
+		}
+		verifiers[form.ID] = captcha.New(
+			provider,
+			c.Secret,
+			captcha.WithEndpoint(c.Endpoint),
+			captcha.WithMinScore(c.MinScore),
+			captcha.WithAction(c.Action),
+			captcha.WithHostnames(c.Hostnames...),
+		)
+	}
+
+	return verifiers
+}
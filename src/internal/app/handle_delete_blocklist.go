@@ -0,0 +1,32 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+func handleDeleteBlocklist(
+	repo *repository.Repository,
+) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		RuleID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(400, Params{"Error": err.Error()})
+		}
+
+		if err := repo.DeleteIPRule(c.Request().Context(), RuleID); err != nil {
+			if errors.Is(err, repository.ErrIPRuleNotFound) {
+				return c.JSON(404, Params{"Error": err.Error()})
+			}
+			logger.Logger.Error().Err(err).Msg("Something went wrong while deleting the IP rule.")
+			return c.JSON(500, Params{"Error": err.Error()})
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+}
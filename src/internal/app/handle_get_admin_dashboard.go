@@ -12,13 +12,9 @@ import (
 
 func handleGetAdminDashboard(
 	repository *repository.Repository,
+	forms models.FormsConfig,
 ) echo.HandlerFunc {
 
-	type ResponseModel struct {
-		Items []models.DecryptedMail `json:"items"`
-		Count int                    `json:"count"`
-	}
-
 	return func(c echo.Context) error {
 		page, pageLen := 1, 10
 
@@ -35,15 +31,25 @@ func handleGetAdminDashboard(
 		}
 
 		offset := (page - 1) * pageLen
+		spam := c.QueryParam("spam") == "true"
 
-		items, count, err := repository.GetMails(offset, pageLen)
+		items, count, err := repository.GetMailsWithContent(c.Request().Context(), offset, pageLen, spam)
 
 		if err != nil {
 			logger.Logger.Error().Err(err).Msg("Something went wrong while querying database.")
 			return c.Render(500, "error", Params{"Error": err.Error()})
 		}
 
-		params := Params{"Mails": items, "Count": count}
+		ids := make([]int32, len(items))
+		for i, item := range items {
+			ids[i] = item.ID
+		}
+		if err := audit(c, repository, models.AuditView, models.AuditMail, ids, ""); err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while writing the audit log.")
+			return c.Render(500, "error", Params{"Error": err.Error()})
+		}
+
+		params := Params{"Mails": items, "Count": count, "Spam": spam, "ApiKey": c.QueryParam("apiKey"), "Forms": forms.Forms}
 		return c.Render(http.StatusOK, "admin", params)
 	}
 }
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+func handleGetAdminMail(
+	repo *repository.Repository,
+) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		MailID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.Render(400, "error", Params{"Error": err.Error()})
+		}
+
+		mail, err := repo.GetMailByID(c.Request().Context(), MailID)
+		if err != nil {
+			if errors.Is(err, repository.ErrMailNotFound) {
+				return c.Render(404, "error", Params{"Error": err.Error()})
+			}
+			logger.Logger.Error().Err(err).Msg("Something went wrong while querying database.")
+			return c.Render(500, "error", Params{"Error": err.Error()})
+		}
+
+		if err := audit(c, repo, models.AuditView, models.AuditMail, []int32{mail.ID}, ""); err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while writing the audit log.")
+			return c.Render(500, "error", Params{"Error": err.Error()})
+		}
+
+		return c.Render(http.StatusOK, "admin_mail", Params{"Mail": mail, "ApiKey": c.QueryParam("apiKey")})
+	}
+}
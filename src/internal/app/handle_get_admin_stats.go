@@ -0,0 +1,28 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+)
+
+// The charts are drawn in the browser from /api/stats, the page only keeps the
+// time range and bucket of the form.
+func handleGetAdminStats() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		bucket := c.QueryParam("bucket")
+		if bucket == "" {
+			bucket = models.BucketDay
+		}
+
+		params := Params{
+			"ApiKey":  c.QueryParam("apiKey"),
+			"From":    c.QueryParam("from"),
+			"To":      c.QueryParam("to"),
+			"Bucket":  bucket,
+			"Buckets": []string{models.BucketHour, models.BucketDay, models.BucketWeek, models.BucketMonth},
+		}
+		return c.Render(http.StatusOK, "admin_stats", params)
+	}
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+// Reads the filters of the audit log from the query params.
+func auditFilter(c echo.Context) (models.AuditFilter, error) {
+	filter := models.AuditFilter{
+		Actor:    c.QueryParam("actor"),
+		Action:   c.QueryParam("action"),
+		Resource: c.QueryParam("resource"),
+	}
+	var err error
+
+	if from := c.QueryParam("from"); from != "" {
+		if filter.CreatedAfter, err = parseDate(from); err != nil {
+			return filter, errors.New("Invalid from: " + from)
+		}
+	}
+
+	if to := c.QueryParam("to"); to != "" {
+		if filter.CreatedBefore, err = parseDate(to); err != nil {
+			return filter, errors.New("Invalid to: " + to)
+		}
+	}
+
+	if record := c.QueryParam("record"); record != "" {
+		id, err := strconv.ParseInt(record, 10, 32)
+		if err != nil {
+			return filter, errors.New("Invalid record: " + record)
+		}
+		recordID := int32(id)
+		filter.RecordID = &recordID
+	}
+
+	return filter, nil
+}
+
+// Returns who viewed, exported, resent or deleted which records, newest first.
+func handleGetAudit(
+	repository *repository.Repository,
+) echo.HandlerFunc {
+
+	type ResponseModel struct {
+		Items      []models.AuditEntry `json:"items"`
+		NextCursor string              `json:"next_cursor,omitempty"`
+	}
+
+	return func(c echo.Context) error {
+		limit := 50
+		if limitParam := c.QueryParam("limit"); limitParam != "" {
+			if v, err := strconv.Atoi(limitParam); err == nil && v > 0 && v <= 1000 {
+				limit = v
+			}
+		}
+
+		filter, err := auditFilter(c)
+		if err != nil {
+			return c.JSON(400, Params{"Error": err.Error()})
+		}
+
+		var cursor *int32
+		if cursorParam := c.QueryParam("cursor"); cursorParam != "" {
+			id, err := strconv.ParseInt(cursorParam, 10, 32)
+			if err != nil {
+				return c.JSON(400, Params{"Error": models.ErrInvalidCursor.Error()})
+			}
+			cursorID := int32(id)
+			cursor = &cursorID
+		}
+
+		items, next, err := repository.GetAuditLog(c.Request().Context(), filter, cursor, limit)
+		if err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while querying database.")
+			return c.JSON(500, Params{"Error": err.Error()})
+		}
+
+		response := ResponseModel{Items: items}
+		if next != nil {
+			response.NextCursor = strconv.Itoa(int(*next))
+		}
+
+		return c.JSON(http.StatusOK, response)
+	}
+}
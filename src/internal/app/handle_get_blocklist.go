@@ -0,0 +1,29 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+func handleGetBlocklist(
+	repository *repository.Repository,
+) echo.HandlerFunc {
+
+	type ResponseModel struct {
+		Items []models.IPRule `json:"items"`
+	}
+
+	return func(c echo.Context) error {
+		items, err := repository.GetIPRules(c.Request().Context())
+		if err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while querying database.")
+			return c.JSON(500, Params{"Error": err.Error()})
+		}
+
+		return c.JSON(http.StatusOK, ResponseModel{Items: items})
+	}
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/pkg/captcha"
+)
+
+// Issues a proof-of-work challenge for the form in the form query param.
+func handleGetCaptchaChallenge(forms models.FormsConfig, captchas map[uuid.UUID]captcha.Verifier) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		form, err := forms.Lookup(c.QueryParam("form"))
+		if err != nil {
+			return c.JSON(http.StatusNotFound, Params{"Error": err.Error()})
+		}
+
+		pow, ok := captchas[form.ID].(*captcha.ProofOfWork)
+		if !ok {
+			return c.JSON(http.StatusNotFound, Params{"Error": "Form does not use the proof-of-work captcha."})
+		}
+
+		challenge, err := pow.Challenge()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, Params{"Error": err.Error()})
+		}
+
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+		return c.JSON(http.StatusOK, Params{"challenge": challenge, "difficulty": pow.Difficulty()})
+	}
+}
@@ -11,6 +11,21 @@ func handleGetConfig(
 	fc models.FormsConfig,
 ) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		return c.JSONPretty(http.StatusOK, fc, " ")
+		return c.JSONPretty(http.StatusOK, redactSecrets(fc), " ")
 	}
 }
+
+// Captcha secrets do not leave the server.
+func redactSecrets(fc models.FormsConfig) models.FormsConfig {
+	forms := make([]models.FormTemplate, len(fc.Forms))
+	for i, form := range fc.Forms {
+		if form.Captcha != nil && form.Captcha.Secret != "" {
+			redacted := *form.Captcha
+			redacted.Secret = "********"
+			form.Captcha = &redacted
+		}
+		forms[i] = form
+	}
+	fc.Forms = forms
+	return fc
+}
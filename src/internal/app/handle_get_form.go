@@ -0,0 +1,78 @@
+package app
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/pkg/captcha"
+)
+
+// Returns the form of the request, only forms with fields can be rendered.
+func getFormWithFields(ctx echo.Context, forms models.FormsConfig) (*models.FormTemplate, error) {
+	form, err := forms.Lookup(ctx.Param("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if !form.HasPage() {
+		return nil, models.ErrFormNotFound
+	}
+
+	return form, nil
+}
+
+// Everything the form templates need, the action is absolute when the form is
+// embedded in another website.
+func formParams(ctx echo.Context, form *models.FormTemplate, defaultCaptcha models.Captcha, baseURL string) Params {
+	language := ctx.QueryParam("language")
+	country := ctx.QueryParam("country")
+
+	if language == "" {
+		language = "NL"
+	}
+
+	if country == "" {
+		country = "BE"
+	}
+
+	query := url.Values{"language": {language}, "country": {country}}
+	csrfToken, _ := ctx.Get(middleware.DefaultCSRFConfig.ContextKey).(string)
+
+	var widget Params
+	c := form.GetCaptcha(defaultCaptcha)
+	if c.Provider == captcha.ProofOfWorkProvider.Name && !form.Skipcaptcha {
+		widget = Params{
+			"Challenge": baseURL + "/captcha/challenge?" + url.Values{"form": {form.Path()}}.Encode(),
+			"Script":    baseURL + captcha.ProofOfWorkProvider.Script,
+			"Class":     captcha.ProofOfWorkProvider.WidgetClass,
+		}
+	} else if c.SiteKey != "" && !form.Skipcaptcha {
+		provider := captcha.Providers[c.Provider]
+		widget = Params{"SiteKey": c.SiteKey, "Script": provider.Script, "Class": provider.WidgetClass}
+	}
+
+	return Params{
+		"Form":      form,
+		"Action":    baseURL + "/forms/" + form.Path() + "?" + query.Encode(),
+		"BaseURL":   baseURL,
+		"Language":  language,
+		"Country":   country,
+		"CSRFToken": csrfToken,
+		"Captcha":   widget,
+	}
+}
+
+// Serves the hosted page of a form, built from the fields of the form.
+func handleGetForm(forms models.FormsConfig, defaultCaptcha models.Captcha) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		form, err := getFormWithFields(ctx, forms)
+		if err != nil {
+			return ctx.Render(http.StatusNotFound, "error", Params{"Error": err.Error()})
+		}
+
+		return ctx.Render(http.StatusOK, "form", formParams(ctx, form, defaultCaptcha, ""))
+	}
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/pkg/renderer"
+)
+
+// Inserts the snippet before the script tag that loaded it, scripts added with
+// innerHTML do not run so they are added again.
+const embedScript = `(function () {
+    var script = document.currentScript;
+    var container = document.createElement("div");
+    container.innerHTML = %s;
+    container.querySelectorAll("script").forEach(function (old) {
+        var loaded = document.createElement("script");
+        loaded.src = old.src;
+        loaded.async = true;
+        old.replaceWith(loaded);
+    });
+    script.parentNode.insertBefore(container, script);
+})();
+`
+
+func baseURL(ctx echo.Context) string {
+	return ctx.Scheme() + "://" + ctx.Request().Host
+}
+
+// Serves the form as HTML snippet to paste in another website.
+func handleGetFormSnippet(forms models.FormsConfig, renderer *renderer.RenderEngine, defaultCaptcha models.Captcha) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		form, err := getFormWithFields(ctx, forms)
+		if err != nil {
+			return ctx.String(http.StatusNotFound, err.Error())
+		}
+
+		snippet, err := renderer.RenderPartial("snippet", formParams(ctx, form, defaultCaptcha, baseURL(ctx)))
+		if err != nil {
+			return ctx.String(http.StatusInternalServerError, err.Error())
+		}
+
+		return ctx.HTMLBlob(http.StatusOK, snippet)
+	}
+}
+
+// Serves the form as script that renders the snippet where the script tag is.
+func handleGetFormEmbed(forms models.FormsConfig, renderer *renderer.RenderEngine, defaultCaptcha models.Captcha) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		form, err := getFormWithFields(ctx, forms)
+		if err != nil {
+			return ctx.String(http.StatusNotFound, err.Error())
+		}
+
+		snippet, err := renderer.RenderPartial("snippet", formParams(ctx, form, defaultCaptcha, baseURL(ctx)))
+		if err != nil {
+			return ctx.String(http.StatusInternalServerError, err.Error())
+		}
+
+		// JSON escapes <, > and & so the snippet can not end the script
+		html, err := json.Marshal(string(snippet))
+		if err != nil {
+			return ctx.String(http.StatusInternalServerError, err.Error())
+		}
+
+		return ctx.Blob(http.StatusOK, echo.MIMEApplicationJavaScriptCharsetUTF8, []byte(fmt.Sprintf(embedScript, html)))
+	}
+}
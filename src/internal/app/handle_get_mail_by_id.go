@@ -6,6 +6,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/sevaho/goforms/src/pkg/logger"
+	"github.com/sevaho/goforms/src/internal/models"
 	"github.com/sevaho/goforms/src/internal/repository"
 )
 
@@ -18,13 +19,18 @@ func handleGetMailByID(
 			return c.JSON(500, Params{"Error": err.Error()})
 		}
 
-		result, err := repository.GetMailByID(int(MailID))
+		result, err := repository.GetMailByID(c.Request().Context(), int(MailID))
 
 		if err != nil {
 			logger.Logger.Error().Err(err).Msg("Something went wrong while querying database.")
 			return c.JSON(500, Params{"Error": err.Error()})
 		}
 
+		if err := audit(c, repository, models.AuditView, models.AuditMail, []int32{result.ID}, ""); err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while writing the audit log.")
+			return c.JSON(500, Params{"Error": err.Error()})
+		}
+
 		return c.JSON(http.StatusOK, result)
 
 	}
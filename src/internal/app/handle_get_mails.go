@@ -1,8 +1,11 @@
 package app
 
 import (
+	"errors"
 	"net/http"
+	"net/mail"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sevaho/goforms/src/pkg/logger"
@@ -10,13 +13,94 @@ import (
 	"github.com/sevaho/goforms/src/internal/repository"
 )
 
+// Dates are either a day, eg. 2025-10-19, or a time in RFC 3339.
+func parseDate(value string) (*time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func parseBool(value string) (*bool, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Reads the filters of a mail search from the query params, spam defaults to
+// the inbox and spam=all returns both.
+func mailFilter(c echo.Context, forms models.FormsConfig) (models.MailFilter, error) {
+	var filter models.MailFilter
+	var err error
+
+	if from := c.QueryParam("from"); from != "" {
+		if filter.CreatedAfter, err = parseDate(from); err != nil {
+			return filter, errors.New("Invalid from: " + from)
+		}
+	}
+
+	if to := c.QueryParam("to"); to != "" {
+		if filter.CreatedBefore, err = parseDate(to); err != nil {
+			return filter, errors.New("Invalid to: " + to)
+		}
+	}
+
+	if formParam := c.QueryParam("form"); formParam != "" {
+		form, err := forms.Lookup(formParam)
+		if err != nil {
+			return filter, errors.New("Unknown form: " + formParam)
+		}
+		filter.FormID = &form.ID
+	}
+
+	filter.Provider = c.QueryParam("provider")
+
+	if success := c.QueryParam("success"); success != "" {
+		if filter.Success, err = parseBool(success); err != nil {
+			return filter, errors.New("Success should be either true or false.")
+		}
+	}
+
+	switch spam := c.QueryParam("spam"); spam {
+	case "all":
+	case "":
+		filter.Spam = new(bool)
+	default:
+		if filter.Spam, err = parseBool(spam); err != nil {
+			return filter, errors.New("Spam should be either true, false or all.")
+		}
+	}
+
+	// Encrypted fields are looked up through their blind index
+	if email := c.QueryParam("email"); email != "" {
+		if !isEmail(email) {
+			return filter, errors.New("Invalid email address.")
+		}
+		filter.Email = email
+	}
+
+	filter.Field = c.QueryParam("field")
+	filter.Value = c.QueryParam("value")
+
+	return filter, nil
+}
+
 func handleGetMails(
 	repository *repository.Repository,
+	forms models.FormsConfig,
 ) echo.HandlerFunc {
 
 	type ResponseModel struct {
 		Items []models.DecryptedMail `json:"items"`
-		Count int                    `json:"count"`
+		// Only the first page is counted
+		Count      *int   `json:"count,omitempty"`
+		NextCursor string `json:"next_cursor,omitempty"`
 	}
 
 	return func(c echo.Context) error {
@@ -36,13 +120,47 @@ func handleGetMails(
 
 		offset := (page - 1) * pageLen
 
-		items, count, err := repository.GetMails(offset, pageLen)
+		filter, err := mailFilter(c, forms)
+		if err != nil {
+			return c.JSON(400, Params{"Error": err.Error()})
+		}
+
+		// The cursor replaces the page, it stays stable while new mails come in
+		var cursor *models.MailCursor
+		if cursorParam := c.QueryParam("cursor"); cursorParam != "" {
+			parsed, err := models.ParseMailCursor(cursorParam)
+			if err != nil {
+				return c.JSON(400, Params{"Error": err.Error()})
+			}
+			cursor, offset = &parsed, 0
+		}
+
+		items, count, next, err := repository.SearchMails(c.Request().Context(), filter, cursor, offset, pageLen)
 
 		if err != nil {
 			logger.Logger.Error().Err(err).Msg("Something went wrong while querying database.")
 			return c.JSON(500, Params{"Error": err.Error()})
 		}
 
-		return c.JSON(http.StatusOK, ResponseModel{Items: items, Count: count})
+		ids := make([]int32, len(items))
+		for i, item := range items {
+			ids[i] = item.ID
+		}
+		if err := audit(c, repository, models.AuditView, models.AuditMail, ids, ""); err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while writing the audit log.")
+			return c.JSON(500, Params{"Error": err.Error()})
+		}
+
+		response := ResponseModel{Items: items, Count: count}
+		if next != nil {
+			response.NextCursor = next.String()
+		}
+
+		return c.JSON(http.StatusOK, response)
 	}
 }
+
+func isEmail(value string) bool {
+	_, err := mail.ParseAddress(value)
+	return err == nil
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+// Streams the mails matching the filters of /api/mails as csv (default),
+// ndjson or xlsx. The fields of the form are columns, ?fields=name,email
+// picks other columns.
+func handleGetMailsExport(
+	repository *repository.Repository,
+	forms models.FormsConfig,
+) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		format := c.QueryParam("format")
+		if format == "" {
+			format = "csv"
+		}
+
+		contentType, ok := exportContentTypes[format]
+		if !ok {
+			return c.JSON(400, Params{"Error": "Format should be csv, ndjson or xlsx."})
+		}
+
+		filter, err := mailFilter(c, forms)
+		if err != nil {
+			return c.JSON(400, Params{"Error": err.Error()})
+		}
+
+		var fields []string
+		if fieldsParam := c.QueryParam("fields"); fieldsParam != "" {
+			fields = strings.Split(fieldsParam, ",")
+		} else if filter.FormID != nil {
+			if form, err := forms.Get(*filter.FormID); err == nil {
+				for _, field := range form.Fields {
+					fields = append(fields, field.Name)
+				}
+			}
+		}
+
+		// Everything that is about to be exported is in the audit log before the
+		// first mail is written.
+		count, err := repository.CountMails(c.Request().Context(), filter)
+		if err == nil {
+			err = auditQuery(c, repository, models.AuditExport, models.AuditMail, count, filter.Email)
+		}
+		if err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while writing the audit log.")
+			return c.JSON(500, Params{"Error": err.Error()})
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, contentType)
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="mails-%s.%s"`, time.Now().UTC().Format("2006-01-02"), format))
+		c.Response().WriteHeader(http.StatusOK)
+
+		exporter, err := newMailExporter(format, c.Response(), fields)
+		if err == nil {
+			err = repository.ExportMails(c.Request().Context(), filter, 500, exporter.Write)
+		}
+		if err == nil {
+			err = exporter.Close()
+		}
+		if err != nil {
+			// The status was sent already, the export ends short
+			logger.Logger.Error().Err(err).Msg("Something went wrong while exporting mails.")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+func handleGetPurgeRuns(
+	repository *repository.Repository,
+) echo.HandlerFunc {
+
+	type ResponseModel struct {
+		Items []models.PurgeRun `json:"items"`
+	}
+
+	return func(c echo.Context) error {
+		limit := 50
+		if limitParam := c.QueryParam("limit"); limitParam != "" {
+			if v, err := strconv.Atoi(limitParam); err == nil && v > 0 && v <= 1000 {
+				limit = v
+			}
+		}
+
+		items, err := repository.GetPurgeRuns(c.Request().Context(), limit)
+		if err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while querying database.")
+			return c.JSON(500, Params{"Error": err.Error()})
+		}
+
+		return c.JSON(http.StatusOK, ResponseModel{Items: items})
+	}
+}
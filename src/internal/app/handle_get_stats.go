@@ -0,0 +1,92 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+// Charts get unreadable long before this, it also keeps an hourly bucket from
+// spanning years.
+const maxStatsBuckets = 1000
+
+var bucketSizes = map[string]time.Duration{
+	models.BucketHour:  time.Hour,
+	models.BucketDay:   24 * time.Hour,
+	models.BucketWeek:  7 * 24 * time.Hour,
+	models.BucketMonth: 30 * 24 * time.Hour,
+}
+
+// Reads the time range and bucket of the statistics from the query params, by
+// default the last 30 days per day.
+func statsFilter(c echo.Context) (models.StatsFilter, error) {
+	filter := models.StatsFilter{To: time.Now().UTC(), Bucket: models.BucketDay}
+
+	if to := c.QueryParam("to"); to != "" {
+		t, err := parseDate(to)
+		if err != nil {
+			return filter, errors.New("Invalid to: " + to)
+		}
+		filter.To = t.UTC()
+	}
+
+	filter.From = filter.To.AddDate(0, 0, -30)
+	if from := c.QueryParam("from"); from != "" {
+		t, err := parseDate(from)
+		if err != nil {
+			return filter, errors.New("Invalid from: " + from)
+		}
+		filter.From = t.UTC()
+	}
+
+	if !filter.From.Before(filter.To) {
+		return filter, errors.New("From should be before to.")
+	}
+
+	if bucket := c.QueryParam("bucket"); bucket != "" {
+		if err := models.ValidateBucket(bucket); err != nil {
+			return filter, err
+		}
+		filter.Bucket = bucket
+	}
+
+	if filter.To.Sub(filter.From)/bucketSizes[filter.Bucket] > maxStatsBuckets {
+		return filter, errors.New("Too many buckets, use a larger bucket or a shorter time range.")
+	}
+
+	return filter, nil
+}
+
+// Returns the mails per bucket and form, the delivery rate per provider, the
+// spam ratio and the top origins of a time range.
+func handleGetStats(
+	repository *repository.Repository,
+	forms models.FormsConfig,
+) echo.HandlerFunc {
+
+	return func(c echo.Context) error {
+		filter, err := statsFilter(c)
+		if err != nil {
+			return c.JSON(400, Params{"Error": err.Error()})
+		}
+
+		stats, err := repository.GetStats(c.Request().Context(), filter)
+		if err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while querying database.")
+			return c.JSON(500, Params{"Error": err.Error()})
+		}
+
+		for i := range stats.Forms {
+			if form, err := forms.Get(stats.Forms[i].FormID); err == nil {
+				stats.Forms[i].Form = form.Name
+			}
+		}
+
+		return c.JSON(http.StatusOK, stats)
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+// Returns everything stored about an email address as JSON, or with
+// ?format=zip as an archive with the JSON and the mails as HTML files.
+func handleGetSubjectExport(
+	repository *repository.Repository,
+) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		email := c.QueryParam("email")
+		if !isEmail(email) {
+			return c.JSON(http.StatusBadRequest, Params{"Error": "Invalid email address."})
+		}
+
+		format := c.QueryParam("format")
+		if format != "" && format != "json" && format != "zip" {
+			return c.JSON(http.StatusBadRequest, Params{"Error": "Format should be either json or zip."})
+		}
+
+		export, err := repository.ExportSubject(c.Request().Context(), email)
+		if err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while exporting the data subject.")
+			return c.JSON(500, Params{"Error": err.Error()})
+		}
+
+		submissionIDs := make([]int32, len(export.Submissions))
+		for i, submission := range export.Submissions {
+			submissionIDs[i] = submission.ID
+		}
+		mailIDs := make([]int32, len(export.Mails))
+		for i, mail := range export.Mails {
+			mailIDs[i] = mail.ID
+		}
+		err = audit(c, repository, models.AuditExport, models.AuditMail, mailIDs, email)
+		if err == nil {
+			err = audit(c, repository, models.AuditExport, models.AuditSubmission, submissionIDs, email)
+		}
+		if err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while writing the audit log.")
+			return c.JSON(500, Params{"Error": err.Error()})
+		}
+
+		if format != "zip" {
+			return c.JSON(http.StatusOK, export)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="subject-export.zip"`)
+		c.Response().WriteHeader(http.StatusOK)
+		return writeSubjectExportZip(c.Response(), export)
+	}
+}
+
+func writeSubjectExportZip(w http.ResponseWriter, export models.SubjectExport) error {
+	archive := zip.NewWriter(w)
+
+	file, err := archive.Create("subject.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return err
+	}
+
+	for _, mail := range export.Mails {
+		file, err := archive.Create(fmt.Sprintf("mails/%d.html", mail.ID))
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write([]byte(mail.Content)); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
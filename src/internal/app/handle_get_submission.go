@@ -0,0 +1,38 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+func handleGetSubmission(
+	repo *repository.Repository,
+) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		SubmissionID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(400, Params{"Error": err.Error()})
+		}
+
+		submission, err := repo.GetSubmission(c.Request().Context(), SubmissionID)
+		if err != nil {
+			if errors.Is(err, repository.ErrSubmissionNotFound) {
+				return c.JSON(404, Params{"Error": err.Error()})
+			}
+			return c.JSON(500, Params{"Error": err.Error()})
+		}
+
+		if err := audit(c, repo, models.AuditView, models.AuditSubmission, []int32{submission.ID}, ""); err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while writing the audit log.")
+			return c.JSON(500, Params{"Error": err.Error()})
+		}
+
+		return c.JSON(http.StatusOK, submission)
+	}
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/pkg/ipfilter"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+func handlePostBlocklist(
+	repository *repository.Repository,
+) echo.HandlerFunc {
+
+	type RequestModel struct {
+		CIDR    string `json:"cidr"`
+		Action  string `json:"action"`
+		Comment string `json:"comment"`
+		// Either an absolute expiry or a duration from now on, eg. "24h".
+		ExpiresAt *time.Time `json:"expires_at"`
+		TTL       string     `json:"ttl"`
+	}
+
+	return func(c echo.Context) error {
+		var body RequestModel
+		if err := c.Bind(&body); err != nil {
+			return c.JSON(400, Params{"Error": err.Error()})
+		}
+
+		prefix, err := ipfilter.ParsePrefix(body.CIDR)
+		if err != nil {
+			return c.JSON(400, Params{"Error": "Invalid IP address or CIDR range: " + body.CIDR})
+		}
+
+		if body.Action == "" {
+			body.Action = models.IPRuleBlock
+		}
+		if body.Action != models.IPRuleBlock && body.Action != models.IPRuleAllow {
+			return c.JSON(400, Params{"Error": "Action should be either block or allow."})
+		}
+
+		expiresAt := body.ExpiresAt
+		if body.TTL != "" {
+			ttl, err := time.ParseDuration(body.TTL)
+			if err != nil || ttl <= 0 {
+				return c.JSON(400, Params{"Error": "Invalid ttl: " + body.TTL})
+			}
+			t := time.Now().UTC().Add(ttl)
+			expiresAt = &t
+		}
+
+		rule, err := repository.StoreIPRule(c.Request().Context(), prefix.String(), body.Action, body.Comment, expiresAt)
+		if err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while storing the IP rule.")
+			return c.JSON(500, Params{"Error": err.Error()})
+		}
+
+		return c.JSON(http.StatusCreated, rule)
+	}
+}
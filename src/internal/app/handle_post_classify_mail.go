@@ -0,0 +1,72 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+func handlePostClassifyMail(
+	repo *repository.Repository,
+) echo.HandlerFunc {
+
+	type RequestModel struct {
+		Label string `json:"label"`
+	}
+
+	return func(c echo.Context) error {
+		MailID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(400, Params{"Error": err.Error()})
+		}
+
+		var body RequestModel
+		if err := c.Bind(&body); err != nil {
+			return c.JSON(400, Params{"Error": err.Error()})
+		}
+
+		if err := repo.ClassifyMail(c.Request().Context(), MailID, body.Label); err != nil {
+			switch {
+			case errors.Is(err, repository.ErrInvalidLabel):
+				return c.JSON(400, Params{"Error": err.Error()})
+			case errors.Is(err, repository.ErrMailNotFound):
+				return c.JSON(404, Params{"Error": err.Error()})
+			case errors.Is(err, repository.ErrClassificationChanged):
+				return c.JSON(409, Params{"Error": err.Error()})
+			default:
+				logger.Logger.Error().Err(err).Msg("Something went wrong while training the classifier.")
+				return c.JSON(500, Params{"Error": err.Error()})
+			}
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+func handlePostAdminClassifyMail(
+	repo *repository.Repository,
+) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		MailID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.Render(500, "error", Params{"Error": err.Error()})
+		}
+
+		if err := repo.ClassifyMail(c.Request().Context(), MailID, c.QueryParam("label")); err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while training the classifier.")
+			return c.Render(500, "error", Params{"Error": err.Error()})
+		}
+
+		// Back to the tab the admin came from
+		redirect := "/admin?apiKey=" + url.QueryEscape(c.QueryParam("apiKey"))
+		if c.QueryParam("spam") == "true" {
+			redirect += "&spam=true"
+		}
+		return c.Redirect(http.StatusSeeOther, redirect)
+	}
+}
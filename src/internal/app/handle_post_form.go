@@ -1,8 +1,12 @@
 package app
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/k3a/html2text"
@@ -10,24 +14,157 @@ import (
 	"github.com/sevaho/goforms/src/internal/models"
 	"github.com/sevaho/goforms/src/internal/repository"
 	"github.com/sevaho/goforms/src/mailproviders"
+	"github.com/sevaho/goforms/src/pkg/captcha"
 	"github.com/sevaho/goforms/src/pkg/logger"
-	"github.com/sevaho/goforms/src/pkg/recaptcha"
 	"github.com/sevaho/goforms/src/pkg/renderer"
+	"github.com/sevaho/goforms/src/pkg/spam"
 	"github.com/sevaho/goforms/src/pkg/telegram"
 )
 
-func verifyCaptcha(realIP string, recaptchaResponse string) error {
-	success, err := recaptcha.Confirm(realIP, recaptchaResponse)
-	if !success {
-		logger.Logger.Error().Err(err).Msg("Invalid captcha or no captcha provided.")
+var errStoreFailed = errors.New("Your submission could not be saved, please try again.")
+var errSendFailed = errors.New("Your submission could not be sent.")
 
-		if err != nil {
-			return err
-		} else {
-			return errors.New("Invalid captcha or no captcha provided.")
+// AJAX posts get JSON back instead of a page.
+func wantsJSON(ctx echo.Context) bool {
+	return strings.Contains(ctx.Request().Header.Get(echo.HeaderAccept), echo.MIMEApplicationJSON) ||
+		ctx.Request().Header.Get(echo.HeaderXRequestedWith) == "XMLHttpRequest"
+}
+
+func renderSuccess(ctx echo.Context, language string, country string) error {
+	if wantsJSON(ctx) {
+		return ctx.JSON(http.StatusOK, Params{"success": true})
+	}
+	return ctx.Render(http.StatusOK, "success", Params{"Language": language, "Country": country})
+}
+
+func renderError(ctx echo.Context, status int, err error) error {
+	if wantsJSON(ctx) {
+		return ctx.JSON(status, Params{"success": false, "error": err.Error()})
+	}
+	return ctx.Render(status, "error", Params{"Error": err.Error()})
+}
+
+// Returns the score of the captcha when the provider scores.
+func verifyCaptcha(ctx echo.Context, verifier captcha.Verifier, response string) (*float64, error) {
+	result, err := verifier.Verify(ctx.Request().Context(), ctx.RealIP(), response)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msgf("Invalid captcha or no captcha provided, action: %q hostname: %q", result.Action, result.Hostname)
+		return nil, err
+	}
+	return result.Score, nil
+}
+
+// A mail that is still pending this long after it was stored was left behind
+// by a crash between storing and sending it.
+const pendingTimeout = 5 * time.Minute
+
+// Looks for the submission this one repeats, either a submission with the same
+// Idempotency-Key or an identical submission within the duplicate window that
+// did not fail. Returns whether it was found by its idempotency key. A pending
+// original that was left behind is abandoned so this submission is sent.
+func findOriginalSubmission(
+	ctx context.Context,
+	repo *repository.Repository,
+	formID uuid.UUID,
+	idempotencyKey string,
+	fingerprint string,
+	window time.Duration,
+) (*models.DecryptedMail, bool) {
+	if idempotencyKey != "" {
+		original, err := repo.GetMailByIdempotencyKey(ctx, formID, idempotencyKey)
+		if err == nil && !abandoned(ctx, repo, original) {
+			return &original, true
+		}
+		if err != nil && !errors.Is(err, repository.ErrMailNotFound) {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while looking up the idempotency key.")
+		}
+	}
+
+	if window > 0 {
+		original, err := repo.GetDuplicateMail(ctx, formID, fingerprint, window)
+		if err == nil && (original.Success || original.Spam || original.Pending()) && !abandoned(ctx, repo, original) {
+			return &original, false
+		}
+		if err != nil && !errors.Is(err, repository.ErrMailNotFound) {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while looking for duplicate submissions.")
 		}
 	}
-	return nil
+
+	return nil, false
+}
+
+// Abandons the mail when it is pending for longer than pendingTimeout, returns
+// whether it was.
+func abandoned(ctx context.Context, repo *repository.Repository, mail models.DecryptedMail) bool {
+	if !mail.Pending() || time.Since(mail.CreatedAt) < pendingTimeout {
+		return false
+	}
+
+	ok, err := repo.AbandonMail(ctx, mail.ID)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("Something went wrong while abandoning a pending mail.")
+		return false
+	}
+	if ok {
+		logger.Logger.Warn().Msgf("Abandoned mail %d, it was still pending after %s", mail.ID, pendingTimeout)
+	}
+	return ok
+}
+
+// Replies to a submission that repeats original like the original was replied
+// to. A retry of a submission that is still being sent is asked to try again.
+func renderDuplicate(ctx echo.Context, repo *repository.Repository, original *models.DecryptedMail, byKey bool, language string, country string) error {
+	logger.Logger.Info().Msgf("Suppressed duplicate of mail %d", original.ID)
+	if err := repo.CountDuplicate(ctx.Request().Context(), original.ID); err != nil {
+		logger.Logger.Error().Err(err).Msg("Something went wrong while counting a duplicate.")
+	}
+
+	if byKey && original.Pending() {
+		return renderError(ctx, http.StatusConflict, errors.New("Your submission is still being processed, please try again."))
+	}
+	// The error of the original is of the mail provider, not for the client
+	if byKey && !original.Success && !original.Spam {
+		return renderError(ctx, 500, errSendFailed)
+	}
+	return renderSuccess(ctx, language, country)
+}
+
+// Another request with the same idempotency key stored its mail first.
+func isConcurrentRetry(err error) bool {
+	return errors.Is(err, repository.ErrIdempotencyKeyUsed)
+}
+
+// Replies to a retry that lost the race for its idempotency key like to any
+// other retry.
+func renderConcurrentRetry(ctx echo.Context, repo *repository.Repository, formID uuid.UUID, key string, language string, country string) error {
+	original, err := repo.GetMailByIdempotencyKey(ctx.Request().Context(), formID, key)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("Something went wrong while looking up the idempotency key.")
+		return renderError(ctx, 500, errStoreFailed)
+	}
+	return renderDuplicate(ctx, repo, &original, true, language, country)
+}
+
+// Flags the submission as spam when the classifier is sure enough, a classifier
+// that did not learn enough yet is ignored.
+func checkSpamClassifier(ctx context.Context, repository *repository.Repository, classifier models.SpamClassifier, text string, verdict *spam.Result) {
+	if !classifier.Enabled() {
+		return
+	}
+
+	probability, trained, err := repository.SpamProbability(ctx, text)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("Something went wrong while classifying the submission.")
+		return
+	}
+
+	if trained.Spam < classifier.MinDocuments || trained.Ham < classifier.MinDocuments {
+		return
+	}
+
+	if probability >= classifier.Threshold {
+		verdict.Flag(fmt.Sprintf("classifier: %.2f", probability))
+	}
 }
 
 func handlePostForm(
@@ -36,11 +173,43 @@ func handlePostForm(
 	forms models.FormsConfig,
 	renderer *renderer.RenderEngine,
 	repository *repository.Repository,
+	spamEngines map[uuid.UUID]*spam.Engine,
+	captchas map[uuid.UUID]captcha.Verifier,
+	classifier models.SpamClassifier,
+	duplicateWindow time.Duration,
 ) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
+		// Cancelled when the client disconnects
+		requestCtx := ctx.Request().Context()
+
 		language := ctx.QueryParam("language")
 		country := ctx.QueryParam("country")
-		FormID, err := uuid.Parse(ctx.Param("id"))
+
+		form, err := forms.Lookup(ctx.Param("id"))
+		if err != nil {
+			return renderError(ctx, http.StatusNotFound, err)
+		}
+
+		fieldOrder, formData, err := postedForm(ctx)
+		if err != nil {
+			var httpErr *echo.HTTPError
+			if errors.As(err, &httpErr) && httpErr.Code == http.StatusRequestEntityTooLarge {
+				return renderError(ctx, httpErr.Code, errors.New("The submission is too large."))
+			}
+			return renderError(ctx, 500, err)
+		}
+
+		// Generated forms carry the language in hidden fields
+		if language == "" {
+			language = formData.Get("_language")
+		}
+
+		if country == "" {
+			country = formData.Get("_country")
+		}
+
+		formData.Del("_language")
+		formData.Del("_country")
 
 		if language == "" {
 			language = "NL"
@@ -50,27 +219,49 @@ func handlePostForm(
 			country = "BE"
 		}
 
+		// Check the client IP against the blocklist, when the rules can not be
+		// fetched we rather let the submission through.
+		blocked, err := repository.IsIPBlocked(requestCtx, ctx.RealIP())
 		if err != nil {
-			return ctx.Render(500, "error", Params{"Error": err.Error()})
+			logger.Logger.Error().Err(err).Msg("Something went wrong while checking the blocklist.")
 		}
-
-		form, err := forms.Get(FormID)
-		if err != nil {
-			return ctx.Render(500, "error", Params{"Error": err.Error()})
+		if blocked {
+			logger.Logger.Warn().Msgf("Blocked submission from %s", ctx.RealIP())
+			return renderError(ctx, http.StatusForbidden, errors.New("Your submission was blocked."))
 		}
 
-		formData, err := ctx.FormParams()
-		if err != nil {
-			return ctx.Render(500, "error", Params{"Error": err.Error()})
+		// Every captcha widget has its own response field, none of them belong in the mail
+		verifier, withCaptcha := captchas[form.ID]
+		var captchaResponse string
+		if withCaptcha {
+			captchaResponse = formData.Get(verifier.ResponseField())
+		}
+		for _, provider := range captcha.Providers {
+			formData.Del(provider.ResponseField)
 		}
 
+		// The honeypot field is hidden for people, only bots fill it in
+		honeypot := formData.Get("_gotcha") != ""
+		formData.Del("_gotcha")
+		formData.Del("_csrf")
+
 		// Parse Captcha
-		if !form.Skipcaptcha {
-			if err := verifyCaptcha(ctx.RealIP(), formData.Get("g-recaptcha-response")); err != nil {
-				return ctx.Render(500, "error", Params{"Error": err.Error()})
+		var captchaScore *float64
+		if withCaptcha {
+			captchaScore, err = verifyCaptcha(ctx, verifier, captchaResponse)
+			if err != nil {
+				return renderError(ctx, 500, err)
 			}
 		}
-		formData.Del("g-recaptcha-response")
+
+		// Retries and double clicks are not sent again. This happens after the
+		// captcha check, a retry needs a fresh captcha response like any other
+		// submission so a known Idempotency-Key does not get past the captcha.
+		idempotencyKey := ctx.Request().Header.Get("Idempotency-Key")
+		fingerprint := repository.Fingerprint(form.ID, formData)
+		if original, byKey := findOriginalSubmission(requestCtx, repository, form.ID, idempotencyKey, fingerprint, form.GetDuplicateWindow(duplicateWindow)); original != nil {
+			return renderDuplicate(ctx, repository, original, byKey, language, country)
+		}
 
 		// Set the subject of the email
 		var subject string
@@ -83,7 +274,7 @@ func handlePostForm(
 			err = errors.New("No subject found!")
 			telegram.SendNotification("Error with subject", err.Error())
 			logger.Logger.Error().Err(err).Msg("No subject")
-			return err
+			return renderError(ctx, 500, err)
 		}
 
 		// Parse website
@@ -101,17 +292,76 @@ func handlePostForm(
 
 		plain := html2text.HTML2Text(string(html))
 
+		// Spam is stored so it can be reviewed and released, but it is not sent
+		verdict := spamEngines[form.ID].Check(formData)
+		if honeypot {
+			verdict.Flag("honeypot")
+		}
+		checkSpamClassifier(requestCtx, repository, classifier, plain, &verdict)
+
+		// The fields are kept next to the rendered mail so integrations do not have to parse it
+		fields := submissionFields(fieldOrder, formData)
+		var submissionID *int32
+		if id, err := repository.StoreSubmission(requestCtx, form.ID, fields); err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while storing the submission.")
+		} else {
+			submissionID = &id
+		}
+
+		meta := models.SubmissionMeta{
+			FormID:         form.ID,
+			Fingerprint:    fingerprint,
+			IdempotencyKey: idempotencyKey,
+			Spam:           verdict,
+			CaptchaScore:   captchaScore,
+			SubmissionID:   submissionID,
+			Metadata:       submissionMetadata(ctx, form.GetPrivacy(), language, country, repository.Hash),
+			Fields:         fields,
+			Searchable:     form.Searchable,
+		}
+
+		if verdict.Spam {
+			logger.Logger.Warn().Msgf("Submission for form %s tagged as spam with score %.1f: %v", form.ID, verdict.Score, verdict.Reasons)
+			// Spam is not sent, when it is not stored either the submission is lost
+			if _, err := repository.Store(requestCtx, form.Provider, subject, string(html), form.Sender.Email, form.Recipients, meta, nil); err != nil {
+				if isConcurrentRetry(err) {
+					return renderConcurrentRetry(ctx, repository, form.ID, idempotencyKey, language, country)
+				}
+				logger.Logger.Error().Err(err).Msg("Something went wrong while storing the mail.")
+				return renderError(ctx, 500, errStoreFailed)
+			}
+			return renderSuccess(ctx, language, country)
+		}
+
+		// The mail is stored before it is sent so a concurrent retry or duplicate
+		// finds it. A mail that can not be stored is not sent either, it would
+		// escape the duplicate and idempotency checks.
+		id, err := repository.Reserve(requestCtx, form.Provider, subject, string(html), form.Sender.Email, form.Recipients, meta)
+		if isConcurrentRetry(err) {
+			return renderConcurrentRetry(ctx, repository, form.ID, idempotencyKey, language, country)
+		}
+		if err != nil {
+			telegram.SendNotification("Error storing mail", err.Error())
+			logger.Logger.Error().Err(err).Msg("Something went wrong while storing the mail.")
+			return renderError(ctx, 500, errStoreFailed)
+		}
+
 		err = mailproviders.Get(form.Provider).Mail(string(html), plain, subject, form.Sender, form.Recipients)
 
-		repository.Store(form.Provider, subject, form.Sender.Email, string(html), form.Recipients, err)
+		// The mail went out, a client that disconnects now should not keep the
+		// outcome from being stored.
+		if storeErr := repository.SetMailResult(context.WithoutCancel(requestCtx), id, err); storeErr != nil {
+			telegram.SendNotification("Error storing mail", storeErr.Error())
+			logger.Logger.Error().Err(storeErr).Msg("Something went wrong while storing the outcome of the mail.")
+		}
 
 		if err != nil {
 			telegram.SendNotification("Error with mailersend", err.Error())
 			logger.Logger.Error().Err(err).Msg("Something went wrong while sending email with mailersend.")
-			return err
+			return renderError(ctx, 500, err)
 		}
 
 		// Return with success page
-		return ctx.Render(http.StatusOK, "success", Params{"Language": language, "Country": country})
+		return renderSuccess(ctx, language, country)
 	}
 }
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/mailproviders"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+var errMailNotSpam = errors.New("mail is not tagged as spam")
+
+// Sends a submission that was tagged as spam after all, the outcome is stored
+// on the mail. The resend is in the audit log before the mail is sent. The mail
+// is taken out of the spam before it is sent, so concurrent releases send it
+// only once.
+func releaseMail(
+	c echo.Context,
+	id int,
+	forms models.FormsConfig,
+	mailproviders *mailproviders.MailProviders,
+	repository *repository.Repository,
+) error {
+	mail, err := repository.GetMailByID(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if !mail.Spam {
+		return errMailNotSpam
+	}
+
+	form, err := forms.Get(mail.FormID)
+	if err != nil {
+		return err
+	}
+
+	if err := audit(c, repository, models.AuditResend, models.AuditMail, []int32{mail.ID}, ""); err != nil {
+		return err
+	}
+
+	released, err := repository.ReleaseMail(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	if !released {
+		return errMailNotSpam
+	}
+
+	err = mailproviders.Get(form.Provider).Mail(mail.Content, mail.ContentPlainText, mail.Subject, form.Sender, form.Recipients)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msgf("Something went wrong while sending released mail %d.", id)
+	}
+
+	// The mail went out, a client that disconnects now should not keep the
+	// outcome from being stored.
+	if storeErr := repository.SetMailResult(context.WithoutCancel(c.Request().Context()), mail.ID, err); storeErr != nil {
+		return storeErr
+	}
+	return err
+}
+
+func handlePostReleaseMail(
+	forms models.FormsConfig,
+	mailproviders *mailproviders.MailProviders,
+	repo *repository.Repository,
+) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		MailID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(400, Params{"Error": err.Error()})
+		}
+
+		if err := releaseMail(c, MailID, forms, mailproviders, repo); err != nil {
+			switch {
+			case errors.Is(err, repository.ErrMailNotFound):
+				return c.JSON(404, Params{"Error": err.Error()})
+			case errors.Is(err, errMailNotSpam):
+				return c.JSON(409, Params{"Error": err.Error()})
+			default:
+				return c.JSON(500, Params{"Error": err.Error()})
+			}
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+func handlePostAdminReleaseMail(
+	forms models.FormsConfig,
+	mailproviders *mailproviders.MailProviders,
+	repo *repository.Repository,
+) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		MailID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.Render(500, "error", Params{"Error": err.Error()})
+		}
+
+		if err := releaseMail(c, MailID, forms, mailproviders, repo); err != nil {
+			return c.Render(500, "error", Params{"Error": err.Error()})
+		}
+
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin?spam=true&apiKey=%s", url.QueryEscape(c.QueryParam("apiKey"))))
+	}
+}
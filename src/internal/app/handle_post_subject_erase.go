@@ -0,0 +1,60 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+func handlePostSubjectErase(
+	repository *repository.Repository,
+) echo.HandlerFunc {
+
+	type RequestModel struct {
+		Email string `json:"email"`
+		// delete (default) removes the mails, shred only wipes their personal data
+		Mode string `json:"mode"`
+	}
+
+	return func(c echo.Context) error {
+		var body RequestModel
+		if err := c.Bind(&body); err != nil {
+			return c.JSON(400, Params{"Error": err.Error()})
+		}
+
+		if !isEmail(body.Email) {
+			return c.JSON(http.StatusBadRequest, Params{"Error": "Invalid email address."})
+		}
+
+		if body.Mode == "" {
+			body.Mode = models.ErasureDelete
+		}
+		if body.Mode != models.ErasureDelete && body.Mode != models.ErasureShred {
+			return c.JSON(400, Params{"Error": "Mode should be either delete or shred."})
+		}
+
+		erasure, err := repository.EraseSubject(c.Request().Context(), body.Email, body.Mode)
+		if err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while erasing the data subject.")
+			return c.JSON(500, Params{"Error": err.Error()})
+		}
+
+		// The data is gone already, a failing audit log is only logged
+		action := models.AuditDelete
+		if erasure.Mode == models.ErasureShred {
+			action = models.AuditShred
+		}
+		err = audit(c, repository, action, models.AuditMail, erasure.MailIDs, body.Email)
+		if err == nil {
+			err = audit(c, repository, models.AuditDelete, models.AuditSubmission, erasure.SubmissionIDs, body.Email)
+		}
+		if err != nil {
+			logger.Logger.Error().Err(err).Msg("Something went wrong while writing the audit log.")
+		}
+
+		return c.JSON(http.StatusOK, erasure)
+	}
+}
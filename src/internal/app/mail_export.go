@@ -0,0 +1,210 @@
+package app
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// Formats mails for an export, Close writes what is left.
+type mailExporter interface {
+	Write(mail models.DecryptedMailWithContent) error
+	Close() error
+}
+
+var exportContentTypes = map[string]string{
+	"csv":    "text/csv",
+	"ndjson": "application/x-ndjson",
+	"xlsx":   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+func newMailExporter(format string, w io.Writer, fields []string) (mailExporter, error) {
+	switch format {
+	case "csv":
+		return newCSVExporter(w, fields)
+	case "ndjson":
+		return &ndjsonExporter{encoder: json.NewEncoder(w)}, nil
+	case "xlsx":
+		return newXLSXExporter(w, fields)
+	}
+	return nil, fmt.Errorf("Format should be csv, ndjson or xlsx.")
+}
+
+// Spreadsheets get a column per field, the fields that have no column go in
+// "Other fields". Mails without fields, stored before submissions were kept,
+// have their content in "Content".
+func exportHeader(fields []string) []string {
+	header := []string{"ID", "Created at", "Form", "Provider", "Success", "Spam", "Subject", "Sender", "Recipients"}
+	header = append(header, fields...)
+	return append(header, "Other fields", "Content")
+}
+
+// Spreadsheet apps run a cell that starts with one of these as a formula, eg.
+// a submitted =HYPERLINK(...).
+const formulaPrefixes = "=+-@\t\r"
+
+// Prefixes a value that would run as a formula with a quote so it stays text.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// The values are escaped, everything in a mail came from a visitor.
+func exportRow(mail models.DecryptedMailWithContent, fields []string) []string {
+	row := mailRow(mail, fields)
+	for i, value := range row {
+		row[i] = escapeFormula(value)
+	}
+	return row
+}
+
+func mailRow(mail models.DecryptedMailWithContent, fields []string) []string {
+	row := []string{
+		strconv.Itoa(int(mail.ID)),
+		mail.CreatedAt.Format(time.DateTime),
+		mail.FormID.String(),
+		mail.MailProvider,
+		strconv.FormatBool(mail.Success),
+		strconv.FormatBool(mail.Spam),
+		mail.Subject,
+		mail.MailFrom,
+		strings.Join(mail.Recipients, ", "),
+	}
+
+	values := make([][]string, len(fields))
+	var other []string
+	for _, field := range mail.Fields {
+		if i := slices.Index(fields, field.Name); i >= 0 {
+			values[i] = append(values[i], field.Value)
+		} else {
+			other = append(other, field.Name+": "+field.Value)
+		}
+	}
+
+	for _, value := range values {
+		row = append(row, strings.Join(value, ", "))
+	}
+	row = append(row, strings.Join(other, "\n"))
+
+	if len(mail.Fields) == 0 {
+		return append(row, mail.ContentPlainText)
+	}
+	return append(row, "")
+}
+
+type csvExporter struct {
+	writer *csv.Writer
+	fields []string
+}
+
+func newCSVExporter(w io.Writer, fields []string) (*csvExporter, error) {
+	e := &csvExporter{writer: csv.NewWriter(w), fields: fields}
+	return e, e.writer.Write(exportHeader(fields))
+}
+
+func (e *csvExporter) Write(mail models.DecryptedMailWithContent) error {
+	return e.writer.Write(exportRow(mail, e.fields))
+}
+
+func (e *csvExporter) Close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// One JSON object per line with the fields as they were posted.
+type ndjsonExporter struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExporter) Write(mail models.DecryptedMailWithContent) error {
+	record := struct {
+		ID           int32
+		CreatedAt    time.Time
+		FormID       string
+		MailProvider string
+		Success      bool
+		Spam         bool
+		Subject      string
+		MailFrom     string
+		Recipients   []string
+		Fields       []models.SubmissionField
+		Content      string `json:",omitempty"`
+	}{
+		ID:           mail.ID,
+		CreatedAt:    mail.CreatedAt,
+		FormID:       mail.FormID.String(),
+		MailProvider: mail.MailProvider,
+		Success:      mail.Success,
+		Spam:         mail.Spam,
+		Subject:      mail.Subject,
+		MailFrom:     mail.MailFrom,
+		Recipients:   mail.Recipients,
+		Fields:       mail.Fields,
+	}
+	if len(mail.Fields) == 0 {
+		record.Content = mail.ContentPlainText
+	}
+	return e.encoder.Encode(record)
+}
+
+func (e *ndjsonExporter) Close() error {
+	return nil
+}
+
+// The stream writer of excelize keeps big sheets in a temporary file, the
+// workbook is written out on Close as it is a zip archive.
+type xlsxExporter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	fields []string
+	row    int
+}
+
+func newXLSXExporter(w io.Writer, fields []string) (*xlsxExporter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		return nil, err
+	}
+
+	e := &xlsxExporter{w: w, file: file, stream: stream, fields: fields}
+	return e, e.writeRow(exportHeader(fields))
+}
+
+func (e *xlsxExporter) writeRow(values []string) error {
+	e.row++
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+
+	row := make([]any, len(values))
+	for i, value := range values {
+		row[i] = value
+	}
+	return e.stream.SetRow(cell, row)
+}
+
+func (e *xlsxExporter) Write(mail models.DecryptedMailWithContent) error {
+	return e.writeRow(exportRow(mail, e.fields))
+}
+
+func (e *xlsxExporter) Close() error {
+	defer e.file.Close()
+
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.w)
+}
@@ -1,8 +1,6 @@
 package app
 
 import (
-	"fmt"
-
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/sevaho/goforms/src/config"
@@ -20,14 +18,20 @@ func QueryKeyAuth(fn middleware.KeyAuthValidator) echo.MiddlewareFunc {
 
 func CheckAuthorizationBearerTokenMiddleware(config *config.Config) echo.MiddlewareFunc {
 	return middleware.KeyAuth(func(key string, c echo.Context) (bool, error) {
-		fmt.Println(c.QueryParams())
-		return config.VerifyApiKey(key), nil
+		if !config.VerifyApiKey(key) {
+			return false, nil
+		}
+		c.Set(auditActorKey, "api-key:"+config.ApiKeyID())
+		return true, nil
 	})
 }
 
 func CheckApiTokenQueryParamsMiddleware(config *config.Config) echo.MiddlewareFunc {
 	return QueryKeyAuth(func(key string, c echo.Context) (bool, error) {
-		fmt.Println(c.QueryParams())
-		return config.VerifyApiKey(key), nil
+		if !config.VerifyApiKey(key) {
+			return false, nil
+		}
+		c.Set(auditActorKey, "api-key:"+config.ApiKeyID())
+		return true, nil
 	})
 }
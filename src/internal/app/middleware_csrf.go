@@ -0,0 +1,33 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/sevaho/goforms/src/internal/models"
+)
+
+// Hands out CSRF tokens on the hosted form pages and checks them on posts to
+// forms that have csrf enabled. Other forms are posted from other websites
+// which never saw the token, so they are skipped.
+func CSRFFormMiddleware(forms models.FormsConfig) echo.MiddlewareFunc {
+	return middleware.CSRFWithConfig(middleware.CSRFConfig{
+		TokenLookup:    "form:_csrf",
+		CookiePath:     "/forms",
+		CookieHTTPOnly: true,
+		CookieSameSite: http.SameSiteLaxMode,
+		Skipper: func(ctx echo.Context) bool {
+			if ctx.Request().Method == http.MethodGet {
+				return false
+			}
+
+			form, err := forms.Lookup(ctx.Param("id"))
+			return err != nil || !form.CSRF
+		},
+		ErrorHandler: func(err error, ctx echo.Context) error {
+			return renderError(ctx, http.StatusForbidden, errors.New("Invalid or missing CSRF token, please reload the form and try again."))
+		},
+	})
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+// Limits the amount of posts per client IP per form, the form can override the
+// default rate limit.
+func RateLimitFormMiddleware(
+	repository *repository.Repository,
+	forms models.FormsConfig,
+	defaultRateLimit models.RateLimit,
+) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			form, err := forms.Lookup(ctx.Param("id"))
+			if err != nil {
+				return next(ctx)
+			}
+
+			rateLimit := form.GetRateLimit(defaultRateLimit)
+			if !rateLimit.Enabled() {
+				return next(ctx)
+			}
+
+			allowed, retryAfter, err := repository.TakeRateLimitToken(ctx.Request().Context(), form.ID.String()+"/"+ctx.RealIP(), rateLimit)
+			if err != nil {
+				// Rather let a request through than refusing everyone when the database hiccups.
+				logger.Logger.Error().Err(err).Msg("Something went wrong while checking the rate limit.")
+				return next(ctx)
+			}
+
+			if !allowed {
+				ctx.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				return renderError(ctx, http.StatusTooManyRequests, errors.New("Too many requests, please try again later."))
+			}
+
+			return next(ctx)
+		}
+	}
+}
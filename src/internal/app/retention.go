@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sevaho/goforms/src/config"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/internal/repository"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+// Retention of every form, forms without one use RETENTION.
+func loadRetention(formsConfig models.FormsConfig, config *config.Config) models.Retention {
+	retention := models.Retention{
+		Default:        config.RETENTION,
+		Forms:          map[uuid.UUID]time.Duration{},
+		KeepStatistics: config.RETENTION_KEEP_STATISTICS,
+	}
+	for _, form := range formsConfig.Forms {
+		retention.Forms[form.ID] = form.GetRetention(config.RETENTION)
+	}
+	return retention
+}
+
+// Longest time any rate limit bucket takes to refill, 0 when no form is rate
+// limited.
+func loadRateLimitRefillTime(formsConfig models.FormsConfig, defaultRateLimit models.RateLimit) time.Duration {
+	var refillTime time.Duration
+	if defaultRateLimit.Enabled() {
+		refillTime = defaultRateLimit.RefillTime()
+	}
+	for _, form := range formsConfig.Forms {
+		if rateLimit := form.GetRateLimit(defaultRateLimit); rateLimit.Enabled() {
+			refillTime = max(refillTime, rateLimit.RefillTime())
+		}
+	}
+	return refillTime
+}
+
+// Purges the expired mails and prunes the full rate limit buckets every
+// interval till the context is done.
+func (app *App) runRetentionJob(ctx context.Context, retention models.Retention, rateLimitRefillTime time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if retention.Enabled() {
+			run, err := app.repository.Purge(ctx, retention, false)
+			if errors.Is(err, repository.ErrPurgeRunning) {
+				logger.Logger.Info().Msg("Skipped purging expired mails, another replica is purging them.")
+			} else if err != nil {
+				logger.Logger.Error().Err(err).Msg("Something went wrong while purging expired mails.")
+			} else {
+				logger.Logger.Info().Msgf("Purged %d mails and %d submissions", run.Mails, run.Submissions)
+			}
+		}
+
+		if rateLimitRefillTime > 0 {
+			pruned, err := app.repository.PruneRateLimits(ctx, rateLimitRefillTime)
+			if err != nil {
+				logger.Logger.Error().Err(err).Msg("Something went wrong while pruning rate limits.")
+			} else {
+				logger.Logger.Info().Msgf("Pruned %d rate limit buckets", pruned)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
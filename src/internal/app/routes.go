@@ -1,27 +1,68 @@
 package app
 
+import (
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/sevaho/goforms/src/internal/models"
+)
+
 func (app *App) addRoutes() {
 	// * * * * * * * * * * * * * * * * * *
 	// SETUP ROUTES
 	// * * * * * * * * * * * * * * * * * *
 	app.server.GET("/", handleGetIndex())
+	app.server.GET("/forms/:id", handleGetForm(app.formsConfig, defaultCaptcha(app.config)), CSRFFormMiddleware(app.formsConfig))
+	app.server.GET("/forms/:id/snippet.html", handleGetFormSnippet(app.formsConfig, app.renderer, defaultCaptcha(app.config)))
+	app.server.GET("/forms/:id/embed.js", handleGetFormEmbed(app.formsConfig, app.renderer, defaultCaptcha(app.config)))
 	app.server.POST("/forms/:id", handlePostForm(
 		app.mailproviders,
 		app.telegram,
 		app.formsConfig,
 		app.renderer,
 		app.repository,
-	))
+		app.spamEngines,
+		app.captchas,
+		models.SpamClassifier{Threshold: app.config.SPAM_CLASSIFIER_THRESHOLD, MinDocuments: app.config.SPAM_CLASSIFIER_MIN_DOCUMENTS},
+		app.config.DUPLICATE_WINDOW,
+	),
+		// Embedded forms post from other websites and read the JSON response
+		middleware.CORS(),
+		middleware.BodyLimit(app.config.MAX_FORM_SIZE),
+		RateLimitFormMiddleware(
+			app.repository,
+			app.formsConfig,
+			defaultRateLimit(app.config),
+		),
+		CSRFFormMiddleware(app.formsConfig),
+	)
+
+	app.server.GET("/captcha/challenge", handleGetCaptchaChallenge(app.formsConfig, app.captchas), middleware.CORS())
 
 	// API
 	apiGroup := app.server.Group("/api")
 	apiGroup.Use(CheckAuthorizationBearerTokenMiddleware(app.config))
 	apiGroup.GET("/config", handleGetConfig(app.formsConfig))
-	apiGroup.GET("/mails", handleGetMails(app.repository))
+	apiGroup.GET("/mails", handleGetMails(app.repository, app.formsConfig))
+	apiGroup.GET("/mails/export", handleGetMailsExport(app.repository, app.formsConfig))
 	apiGroup.GET("/mails/:id", handleGetMailByID(app.repository))
+	apiGroup.POST("/mails/:id/release", handlePostReleaseMail(app.formsConfig, app.mailproviders, app.repository))
+	apiGroup.POST("/mails/:id/classify", handlePostClassifyMail(app.repository))
+	apiGroup.GET("/submissions/:id", handleGetSubmission(app.repository))
+	apiGroup.GET("/blocklist", handleGetBlocklist(app.repository))
+	apiGroup.POST("/blocklist", handlePostBlocklist(app.repository))
+	apiGroup.DELETE("/blocklist/:id", handleDeleteBlocklist(app.repository))
+	apiGroup.GET("/purge-runs", handleGetPurgeRuns(app.repository))
+	apiGroup.GET("/subjects/export", handleGetSubjectExport(app.repository))
+	apiGroup.POST("/subjects/erase", handlePostSubjectErase(app.repository))
+	apiGroup.GET("/audit", handleGetAudit(app.repository))
+	apiGroup.GET("/stats", handleGetStats(app.repository, app.formsConfig))
 
 	// admin
-	app.server.GET("/admin", handleGetAdminDashboard(app.repository), CheckApiTokenQueryParamsMiddleware(app.config))
+	app.server.GET("/admin", handleGetAdminDashboard(app.repository, app.formsConfig), CheckApiTokenQueryParamsMiddleware(app.config))
+	app.server.GET("/admin/stats", handleGetAdminStats(), CheckApiTokenQueryParamsMiddleware(app.config))
+	app.server.GET("/admin/mails/export", handleGetMailsExport(app.repository, app.formsConfig), CheckApiTokenQueryParamsMiddleware(app.config))
+	app.server.GET("/admin/mails/:id", handleGetAdminMail(app.repository), CheckApiTokenQueryParamsMiddleware(app.config))
+	app.server.POST("/admin/mails/:id/classify", handlePostAdminClassifyMail(app.repository), CheckApiTokenQueryParamsMiddleware(app.config))
+	app.server.POST("/admin/mails/:id/release", handlePostAdminReleaseMail(app.formsConfig, app.mailproviders, app.repository), CheckApiTokenQueryParamsMiddleware(app.config))
 
 	// healthz
 	app.server.GET("/healthz", handleGetIndex())
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+)
+
+// Returns the names of the posted fields in the order they were posted, parsing
+// the form loses the order, and the form data. The size of the body is limited
+// by the BodyLimit middleware. Files are skipped without reading them, they
+// are not part of a submission.
+func postedForm(ctx echo.Context) ([]string, url.Values, error) {
+	req := ctx.Request()
+
+	// The CSRF middleware parses the form to read its token, the order is lost
+	if req.Form != nil {
+		formData, err := ctx.FormParams()
+		return nil, formData, err
+	}
+
+	var names []string
+	add := func(name string) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get(echo.HeaderContentType))
+	switch mediaType {
+	case echo.MIMEApplicationForm:
+		// Only the names are taken, the body is put back to parse the form after
+		body, err := io.ReadAll(req.Body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, pair := range strings.Split(string(body), "&") {
+			key, _, _ := strings.Cut(pair, "=")
+			if name, err := url.QueryUnescape(key); err == nil && name != "" {
+				add(name)
+			}
+		}
+	case echo.MIMEMultipartForm:
+		// Parsing a multipart form keeps the files in memory, so the parts are
+		// read one by one instead.
+		reader, err := req.MultipartReader()
+		if err != nil {
+			return nil, nil, err
+		}
+		formData := req.URL.Query()
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			if part.FormName() == "" || part.FileName() != "" {
+				// Closing discards the rest of the part
+				part.Close()
+				continue
+			}
+			value, err := io.ReadAll(part)
+			if err != nil {
+				return nil, nil, err
+			}
+			add(part.FormName())
+			formData.Add(part.FormName(), string(value))
+		}
+		return names, formData, nil
+	}
+
+	formData, err := ctx.FormParams()
+	return names, formData, err
+}
+
+// Orders the form data like it was posted, fields that were not found in the
+// body, eg. query params, are added after in alphabetical order.
+func submissionFields(order []string, formData url.Values) []models.SubmissionField {
+	var fields []models.SubmissionField
+
+	for _, name := range order {
+		for _, value := range formData[name] {
+			fields = append(fields, models.SubmissionField{Name: name, Value: value})
+		}
+	}
+
+	var rest []string
+	for name := range formData {
+		if !slices.Contains(order, name) {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+
+	for _, name := range rest {
+		for _, value := range formData[name] {
+			fields = append(fields, models.SubmissionField{Name: name, Value: value})
+		}
+	}
+
+	return fields
+}
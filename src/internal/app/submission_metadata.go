@@ -0,0 +1,77 @@
+package app
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/pkg/ipfilter"
+)
+
+// Longest request ID that is kept, the client can pick its own X-Request-ID.
+const maxRequestIDLength = 64
+
+// Keeps value when it looks like an ISO code of min to max letters, anything
+// else the client made up is dropped.
+func isoCode(value string, min int, max int) string {
+	if len(value) < min || len(value) > max {
+		return ""
+	}
+	for _, r := range value {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return ""
+		}
+	}
+	return value
+}
+
+// Collects the context of a submission, only keeping what the privacy settings
+// of the form allow. Hash is used for IP addresses that are kept as hash. The
+// language (ISO 639) and country (ISO 3166) come from the client and are only
+// kept when they look like codes.
+func submissionMetadata(
+	ctx echo.Context,
+	privacy models.Privacy,
+	language string,
+	country string,
+	hash func(string) string,
+) models.Metadata {
+	metadata := models.Metadata{
+		Language:  isoCode(language, 2, 3),
+		Country:   isoCode(country, 2, 2),
+		RequestID: ctx.Response().Header().Get(echo.HeaderXRequestID),
+	}
+	if len(metadata.RequestID) > maxRequestIDLength {
+		metadata.RequestID = strings.ToValidUTF8(metadata.RequestID[:maxRequestIDLength], "")
+	}
+
+	switch privacy.IP {
+	case models.PrivacyFull:
+		metadata.IP = ctx.RealIP()
+	case models.PrivacyTruncate:
+		metadata.IP = ipfilter.Truncate(ctx.RealIP())
+	case models.PrivacyHash:
+		metadata.IP = hash(ctx.RealIP())
+	}
+
+	if privacy.UserAgent == models.PrivacyFull {
+		metadata.UserAgent = ctx.Request().UserAgent()
+	}
+
+	origin := ctx.Request().Header.Get(echo.HeaderOrigin)
+	if origin == "" {
+		origin = ctx.Request().Referer()
+	}
+
+	switch privacy.Origin {
+	case models.PrivacyFull:
+		metadata.Origin = origin
+	case models.PrivacyHost:
+		if u, err := url.Parse(origin); err == nil {
+			metadata.Origin = u.Host
+		}
+	}
+
+	return metadata
+}
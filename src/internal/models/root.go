@@ -1,10 +1,15 @@
 package models
 
 import (
+	"encoding/base64"
 	"errors"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sevaho/goforms/src/pkg/spam"
 )
 
 type Recipient struct {
@@ -12,25 +17,271 @@ type Recipient struct {
 	Email string `json:"email"`
 }
 
+// Token bucket, a client can do Burst requests at once after which the bucket
+// refills with PerMinute tokens every minute.
+type RateLimit struct {
+	Burst     int     `json:"burst"`
+	PerMinute float64 `json:"perminute"`
+}
+
+func (r RateLimit) Enabled() bool {
+	return r.Burst > 0 && r.PerMinute > 0
+}
+
+// How long an empty bucket takes to be full again.
+func (r RateLimit) RefillTime() time.Duration {
+	return time.Duration(float64(r.Burst) / r.PerMinute * float64(time.Minute))
+}
+
+// Captcha of a form, provider is recaptcha (v2 and v3), hcaptcha, turnstile or
+// pow, the built-in proof-of-work captcha. Endpoint overrides the verification
+// endpoint of the provider. MinScore and Action only apply to reCAPTCHA v3,
+// Hostnames limits the websites the captcha can be solved on. Difficulty is the
+// amount of leading zero bits a pow solution needs.
+type Captcha struct {
+	Provider   string   `json:"provider"`
+	SiteKey    string   `json:"sitekey"`
+	Secret     string   `json:"secret"`
+	Endpoint   string   `json:"endpoint"`
+	MinScore   float64  `json:"minscore"`
+	Action     string   `json:"action"`
+	Hostnames  []string `json:"hostnames"`
+	Difficulty int      `json:"difficulty"`
+}
+
+// How much metadata is kept of a submission
+const (
+	PrivacyFull     = "full"
+	PrivacyTruncate = "truncate"
+	PrivacyHash     = "hash"
+	PrivacyHost     = "host"
+	PrivacyNone     = "none"
+)
+
+// IP is full, truncate (default), hash or none. UserAgent is full (default) or
+// none. Origin is full, host (default) or none.
+type Privacy struct {
+	IP        string `json:"ip"`
+	UserAgent string `json:"useragent"`
+	Origin    string `json:"origin"`
+}
+
+func (p Privacy) Validate() error {
+	if !slices.Contains([]string{PrivacyFull, PrivacyTruncate, PrivacyHash, PrivacyNone}, p.IP) {
+		return errors.New("ip should be full, truncate, hash or none")
+	}
+	if !slices.Contains([]string{PrivacyFull, PrivacyNone}, p.UserAgent) {
+		return errors.New("useragent should be full or none")
+	}
+	if !slices.Contains([]string{PrivacyFull, PrivacyHost, PrivacyNone}, p.Origin) {
+		return errors.New("origin should be full, host or none")
+	}
+	return nil
+}
+
+// Context of a submission for abuse investigations, reduced to what the privacy
+// settings of the form allow.
+type Metadata struct {
+	IP        string
+	UserAgent string
+	Origin    string
+	Language  string
+	Country   string
+	RequestID string
+}
+
+// Field types a hosted form page can render
+const (
+	FieldText     = "text"
+	FieldEmail    = "email"
+	FieldTel      = "tel"
+	FieldNumber   = "number"
+	FieldURL      = "url"
+	FieldTextarea = "textarea"
+	FieldSelect   = "select"
+	FieldCheckbox = "checkbox"
+)
+
+// A field of the hosted form page, Labels holds the label per language (eg. nl)
+// and falls back to Label.
+type Field struct {
+	Name        string            `json:"name"`
+	Label       string            `json:"label"`
+	Labels      map[string]string `json:"labels"`
+	Type        string            `json:"type"`
+	Required    bool              `json:"required"`
+	Placeholder string            `json:"placeholder"`
+	Options     []string          `json:"options"`
+}
+
+func (f Field) LabelFor(language string) string {
+	if label, ok := f.Labels[strings.ToLower(language)]; ok {
+		return label
+	}
+	if f.Label != "" {
+		return f.Label
+	}
+	return f.Name
+}
+
 // TODO:  <26-04-25, Sebastiaan Van Hoecke> // Add validation logic, fields should be checked because required
 type FormTemplate struct {
-	ID          uuid.UUID   `json:"id"`
+	ID uuid.UUID `json:"id"`
+	// Readable alternative for the ID in urls, eg. /forms/contact
+	Slug        string      `json:"slug"`
 	Skipcaptcha bool        `json:"skipcaptcha"`
 	Recipients  []Recipient `json:"recipients"`
 	Subject     string      `json:"subject"`
 	Name        string      `json:"name"`
 	Sender      Recipient   `json:"sender"`
 	Provider    string      `json:"provider"`
+	RateLimit   *RateLimit  `json:"ratelimit"`
+	// Added to the global spam rules
+	Spam *spam.Config `json:"spam"`
+	// Identical submissions within this window are suppressed, 0 disables it
+	DuplicateWindow *Duration `json:"duplicatewindow"`
+	// Fields of the hosted form page, without fields there is no page
+	Fields      []Field  `json:"fields"`
+	Description string   `json:"description"`
+	Captcha     *Captcha `json:"captcha"`
+	// How much of the submission metadata is kept
+	Privacy *Privacy `json:"privacy"`
+	// Mails and submissions older than this are purged, 0 keeps them forever
+	Retention *Duration `json:"retention"`
+	// Fields the mails can be looked up by with /api/mails?field=&value=
+	Searchable []string `json:"searchable"`
+	// Posts need the CSRF token of the hosted page, only for forms that are not embedded elsewhere
+	CSRF bool `json:"csrf"`
+}
+
+// The slug of the form, or its ID when it has none.
+func (f *FormTemplate) Path() string {
+	if f.Slug != "" {
+		return f.Slug
+	}
+	return f.ID.String()
+}
+
+// Returns the metadata privacy settings of the form with the defaults filled in.
+func (f *FormTemplate) GetPrivacy() Privacy {
+	var p Privacy
+	if f.Privacy != nil {
+		p = *f.Privacy
+	}
+	if p.IP == "" {
+		p.IP = PrivacyTruncate
+	}
+	if p.UserAgent == "" {
+		p.UserAgent = PrivacyFull
+	}
+	if p.Origin == "" {
+		p.Origin = PrivacyHost
+	}
+	return p
+}
+
+// Whether the form is served as a hosted page on GET /forms/:id.
+func (f *FormTemplate) HasPage() bool {
+	return len(f.Fields) != 0
+}
+
+// Returns the duplicate window of the form, or the given default when the form has none.
+func (f *FormTemplate) GetDuplicateWindow(defaultWindow time.Duration) time.Duration {
+	if f.DuplicateWindow != nil {
+		return time.Duration(*f.DuplicateWindow)
+	}
+	return defaultWindow
+}
+
+// Returns the captcha of the form, unset settings are taken from the default
+// when the form uses the same provider.
+func (f *FormTemplate) GetCaptcha(defaultCaptcha Captcha) Captcha {
+	if f.Captcha == nil {
+		return defaultCaptcha
+	}
+
+	c := *f.Captcha
+	if c.Provider == "" {
+		c.Provider = defaultCaptcha.Provider
+	}
+	if c.Provider == defaultCaptcha.Provider {
+		if c.SiteKey == "" {
+			c.SiteKey = defaultCaptcha.SiteKey
+		}
+		if c.Secret == "" {
+			c.Secret = defaultCaptcha.Secret
+		}
+		if c.Endpoint == "" {
+			c.Endpoint = defaultCaptcha.Endpoint
+		}
+	}
+	return c
+}
+
+// Returns the retention of the form, or the given default when the form has none.
+func (f *FormTemplate) GetRetention(defaultRetention time.Duration) time.Duration {
+	if f.Retention != nil {
+		return time.Duration(*f.Retention)
+	}
+	return defaultRetention
+}
+
+// Returns the rate limit of the form, or the given default when the form has none.
+func (f *FormTemplate) GetRateLimit(defaultRateLimit RateLimit) RateLimit {
+	if f.RateLimit != nil {
+		return *f.RateLimit
+	}
+	return defaultRateLimit
 }
 
 type FormsConfig struct {
 	Forms []FormTemplate `json:"forms"`
+	// Spam rules that apply to every form
+	Spam *spam.Config `json:"spam"`
 }
 
 func (c *FormsConfig) Check() bool {
 	return len(c.Forms) != 0
 }
 
+// Slugs have to be unique and can not look like an ID of another form.
+func (c *FormsConfig) Validate() error {
+	slugs := make(map[string]bool, len(c.Forms))
+	for _, v := range c.Forms {
+		if err := v.GetPrivacy().Validate(); err != nil {
+			return errors.New("Invalid privacy settings of form " + v.ID.String() + ": " + err.Error())
+		}
+		if v.Slug == "" {
+			continue
+		}
+		if _, err := uuid.Parse(v.Slug); err == nil {
+			return errors.New("Slug of form " + v.ID.String() + " can not be a UUID: " + v.Slug)
+		}
+		if slugs[v.Slug] {
+			return errors.New("Slug used by more than one form: " + v.Slug)
+		}
+		slugs[v.Slug] = true
+	}
+	return nil
+}
+
+var ErrFormNotFound = errors.New("Form not found.")
+
+// Finds a form by its ID or slug.
+func (c *FormsConfig) Lookup(idOrSlug string) (*FormTemplate, error) {
+	if id, err := uuid.Parse(idOrSlug); err == nil {
+		if form, err := c.Get(id); err == nil {
+			return form, nil
+		}
+	}
+	for _, v := range c.Forms {
+		if v.Slug != "" && v.Slug == idOrSlug {
+			return &v, nil
+		}
+	}
+	return nil, ErrFormNotFound
+}
+
 func (c *FormsConfig) Get(id uuid.UUID) (*FormTemplate, error) {
 	for _, v := range c.Forms {
 		if v.ID == id {
@@ -40,19 +291,311 @@ func (c *FormsConfig) Get(id uuid.UUID) (*FormTemplate, error) {
 	return nil, errors.New("No form found with ID: " + id.String())
 }
 
+// How long mails and submissions are kept, 0 keeps them forever. Forms holds
+// the retention per form, mails of forms that are not in it use Default.
+type Retention struct {
+	Default time.Duration
+	Forms   map[uuid.UUID]time.Duration
+	// Purged mails are counted per day in anonymized statistics
+	KeepStatistics bool
+}
+
+func (r Retention) Enabled() bool {
+	if r.Default > 0 {
+		return true
+	}
+	for _, retention := range r.Forms {
+		if retention > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type PurgeRun struct {
+	ID          int32
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	DryRun      bool
+	Mails       int64
+	Submissions int64
+	Error       string
+}
+
+// Labels admins give mails to train the spam classifier
+const (
+	LabelSpam = "spam"
+	LabelHam  = "ham"
+)
+
+// Submissions are tagged as spam when the classifier is at least Threshold
+// sure, but only after it learned from MinDocuments spam and ham mails.
+type SpamClassifier struct {
+	Threshold    float64
+	MinDocuments int
+}
+
+func (c SpamClassifier) Enabled() bool {
+	return c.Threshold > 0 && c.Threshold <= 1
+}
+
+// Everything about a submission that is stored next to the mail.
+type SubmissionMeta struct {
+	FormID         uuid.UUID
+	Fingerprint    string
+	IdempotencyKey string
+	Spam           spam.Result
+	CaptchaScore   *float64
+	SubmissionID   *int32
+	Metadata       Metadata
+	// Email addresses in the fields and the searchable fields are blind indexed
+	Fields     []SubmissionField
+	Searchable []string
+}
+
+// A submitted field, submissions keep the fields in the order they were posted.
+type SubmissionField struct {
+	Name  string
+	Value string
+}
+
+type Submission struct {
+	ID        int32
+	CreatedAt time.Time
+	FormID    uuid.UUID
+	Fields    []SubmissionField
+	// Mails that were sent for the submission
+	MailIDs []int32
+}
+
+// Filters of a mail search, only the filters that are set apply. Email looks
+// up the mails an address was in, Field and Value the mails with that value in
+// a searchable field.
+type MailFilter struct {
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	FormID        *uuid.UUID
+	Provider      string
+	Success       *bool
+	Spam          *bool
+	Email         string
+	Field         string
+	Value         string
+}
+
+// Position in a mail search, the mails after it are older than the mail it
+// was taken from.
+type MailCursor struct {
+	CreatedAt time.Time
+	ID        int32
+}
+
+func (c MailCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.Format(time.RFC3339Nano) + "|" + strconv.Itoa(int(c.ID))))
+}
+
+var ErrInvalidCursor = errors.New("Invalid cursor.")
+
+func ParseMailCursor(s string) (MailCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return MailCursor{}, ErrInvalidCursor
+	}
+
+	createdAt, id, found := strings.Cut(string(data), "|")
+	if !found {
+		return MailCursor{}, ErrInvalidCursor
+	}
+
+	var cursor MailCursor
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return MailCursor{}, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(id, 10, 32)
+	if err != nil {
+		return MailCursor{}, ErrInvalidCursor
+	}
+	cursor.ID = int32(n)
+	return cursor, nil
+}
+
 type DecryptedMail struct {
-	ID                int32
-	CreatedAt         time.Time
-	MailProvider      string
-	Success           bool
-	MailFrom          string
-	MailFromPlainText string
-	Recipients        []string
-	Subject           string
-	Error             string
+	ID              int32
+	CreatedAt       time.Time
+	MailProvider    string
+	Success         bool
+	MailFrom        string
+	Recipients      []string
+	Subject         string
+	Error           string
+	FormID          uuid.UUID
+	Spam            bool
+	SpamScore       float64
+	SpamReasons     []string
+	ReleasedAt      *time.Time
+	ClassifiedAs    string
+	Duplicates      int32
+	LastDuplicateAt *time.Time
+	CaptchaScore    *float64
+	SubmissionID    *int32
+	Metadata        Metadata
+}
+
+// Pending mails were stored before they were sent and are still being sent.
+func (m DecryptedMail) Pending() bool {
+	return !m.Success && !m.Spam && m.Error == ""
 }
 
 type DecryptedMailWithContent struct {
 	DecryptedMail
-	Content string
+	Content          string
+	ContentPlainText string
+	// Fields of the submission, mails stored before submissions were kept have none
+	Fields []SubmissionField
+}
+
+// Ways to erase the data of a data subject, shred wipes the personal data but
+// keeps the mails for the statistics.
+const (
+	ErasureDelete = "delete"
+	ErasureShred  = "shred"
+)
+
+// Everything that is stored about a data subject.
+type SubjectExport struct {
+	Email       string
+	ExportedAt  time.Time
+	Mails       []DecryptedMailWithContent
+	Submissions []Submission
+}
+
+type SubjectErasure struct {
+	Mode        string
+	Mails       int64
+	Submissions int64
+	// What was erased, for the audit log
+	MailIDs       []int32 `json:"-"`
+	SubmissionIDs []int32 `json:"-"`
+}
+
+const (
+	IPRuleBlock = "block"
+	IPRuleAllow = "allow"
+)
+
+type IPRule struct {
+	ID        int32
+	CreatedAt time.Time
+	CIDR      string
+	Action    string
+	Comment   string
+	ExpiresAt *time.Time
+}
+
+// What the API key was used for, recorded in the audit log
+const (
+	AuditView   = "view"
+	AuditExport = "export"
+	AuditResend = "resend"
+	AuditDelete = "delete"
+	AuditShred  = "shred"
+)
+
+// Records in the audit log
+const (
+	AuditMail       = "mail"
+	AuditSubmission = "submission"
+)
+
+// Who accessed which records and when, Subject is the keyed hash of the email
+// address of a data subject request.
+type AuditEntry struct {
+	ID        int32
+	CreatedAt time.Time
+	Actor     string
+	Action    string
+	Resource  string
+	RecordIDs []int32
+	Subject   string
+	ClientIP  string
+	RequestID string
+	// The query and amount of records of an export, it does not list them
+	Filter  string `json:",omitempty"`
+	Records *int   `json:",omitempty"`
+}
+
+// Filters of the audit log, only the filters that are set apply.
+type AuditFilter struct {
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Actor         string
+	Action        string
+	Resource      string
+	RecordID      *int32
+}
+
+// Sizes of the buckets of the statistics
+const (
+	BucketHour  = "hour"
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+var ErrInvalidBucket = errors.New("Bucket should be hour, day, week or month.")
+
+func ValidateBucket(bucket string) error {
+	if !slices.Contains([]string{BucketHour, BucketDay, BucketWeek, BucketMonth}, bucket) {
+		return ErrInvalidBucket
+	}
+	return nil
+}
+
+// Time range of the statistics, To is exclusive.
+type StatsFilter struct {
+	From   time.Time
+	To     time.Time
+	Bucket string
+}
+
+// Mails of a form in a bucket, FormID is the nil UUID for mails without a form.
+// Success counts the mails that were sent.
+type FormStats struct {
+	Bucket  time.Time
+	FormID  uuid.UUID
+	Form    string
+	Mails   int64
+	Spam    int64
+	Success int64
+}
+
+// Delivery of the mails that were not held as spam by a mail provider.
+type ProviderStats struct {
+	Provider    string
+	Mails       int64
+	Success     int64
+	SuccessRate float64
+}
+
+type SpamStats struct {
+	Mails int64
+	Spam  int64
+	Ratio float64
+}
+
+type OriginStats struct {
+	Origin string
+	Mails  int64
+}
+
+// Statistics of the mails in a time range. The purged mails count in Forms and
+// Spam through the statistics kept of them, they have no provider or origin.
+type Stats struct {
+	From      time.Time
+	To        time.Time
+	Bucket    string
+	Forms     []FormStats
+	Providers []ProviderStats
+	Spam      SpamStats
+	Origins   []OriginStats
 }
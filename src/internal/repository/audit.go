@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+	"github.com/sevaho/goforms/src/internal/models"
+)
+
+// Audit appends the entry to the audit log. The email address of a data
+// subject request is stored as its keyed hash, like in subject_requests.
+func (r *Repository) Audit(ctx context.Context, entry models.AuditEntry, email string) error {
+	params := db.InsertAuditLogParams{
+		CreatedAt: db.TimeToPGTimestamp(time.Now().UTC()),
+		Actor:     entry.Actor,
+		Action:    entry.Action,
+		Resource:  entry.Resource,
+		RecordIds: entry.RecordIDs,
+	}
+
+	if email != "" {
+		params.Subject = db.StringtoPGText(r.subjectIndex(normalizeEmail(email)))
+	}
+	if entry.ClientIP != "" {
+		params.ClientIp = db.StringtoPGText(entry.ClientIP)
+	}
+	if entry.RequestID != "" {
+		params.RequestID = db.StringtoPGText(entry.RequestID)
+	}
+	if entry.Filter != "" {
+		params.Filter = db.StringtoPGText(entry.Filter)
+	}
+	if entry.Records != nil {
+		params.Records = pgtype.Int4{Int32: int32(*entry.Records), Valid: true}
+	}
+	if params.RecordIds == nil {
+		params.RecordIds = []int32{}
+	}
+
+	return r.db.InsertAuditLog(ctx, params)
+}
+
+// GetAuditLog returns the entries matching the filter, newest first, starting
+// after the cursor (the ID of the last entry of the previous page) when there
+// is one. The next cursor is nil on the last page.
+func (r *Repository) GetAuditLog(ctx context.Context, filter models.AuditFilter, cursor *int32, limit int) ([]models.AuditEntry, *int32, error) {
+	params := db.SelectAuditLogParams{Limit: int32(limit + 1)}
+
+	if filter.CreatedAfter != nil {
+		params.CreatedAfter = db.TimeToPGTimestamp(*filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		params.CreatedBefore = db.TimeToPGTimestamp(*filter.CreatedBefore)
+	}
+	if filter.Actor != "" {
+		params.Actor = db.StringtoPGText(filter.Actor)
+	}
+	if filter.Action != "" {
+		params.Action = db.StringtoPGText(filter.Action)
+	}
+	if filter.Resource != "" {
+		params.Resource = db.StringtoPGText(filter.Resource)
+	}
+	if filter.RecordID != nil {
+		params.RecordID = pgtype.Int4{Int32: *filter.RecordID, Valid: true}
+	}
+	if cursor != nil {
+		params.CursorID = pgtype.Int4{Int32: *cursor, Valid: true}
+	}
+
+	// One entry more than asked tells whether there is a next page
+	rows, err := r.db.SelectAuditLog(ctx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *int32
+	if len(rows) > limit {
+		rows = rows[:limit]
+		next = &rows[limit-1].ID
+	}
+
+	entries := make([]models.AuditEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = models.AuditEntry{
+			ID:        row.ID,
+			CreatedAt: row.CreatedAt.Time,
+			Actor:     row.Actor,
+			Action:    row.Action,
+			Resource:  row.Resource,
+			RecordIDs: row.RecordIds,
+			Subject:   row.Subject.String,
+			ClientIP:  row.ClientIp.String,
+			RequestID: row.RequestID.String,
+			Filter:    row.Filter.String,
+		}
+		if row.Records.Valid {
+			records := int(row.Records.Int32)
+			entries[i].Records = &records
+		}
+	}
+	return entries, next, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sevaho/goforms/src/db"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+// The blind index holds keyed hashes of the email addresses of a mail and of
+// the values of its searchable fields, so mails can be looked up by them
+// without decrypting the table. The email addresses submitted in the fields
+// are hashed once more in their own namespace, data subject requests only
+// look at those so a sender or recipient is never taken for the subject.
+
+// Returns the lower cased address, or "" when the value is not an email address.
+func normalizeEmail(value string) string {
+	address, err := mail.ParseAddress(strings.TrimSpace(value))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(address.Address)
+}
+
+func (r *Repository) emailIndex(email string) string {
+	return r.Hash("email\x00" + email)
+}
+
+func (r *Repository) subjectIndex(email string) string {
+	return r.Hash("subject\x00" + email)
+}
+
+func (r *Repository) fieldIndex(name string, value string) string {
+	return r.Hash("field\x00" + strings.ToLower(name) + "\x00" + strings.ToLower(strings.Join(strings.Fields(value), " ")))
+}
+
+// Indexes the sender, the recipients, every submitted value that is an email
+// address and the values of the searchable fields.
+func (r *Repository) blindIndex(mailFrom string, recipients []string, fields []models.SubmissionField, searchable []string) []string {
+	index := []string{}
+	add := func(hash string) {
+		if !slices.Contains(index, hash) {
+			index = append(index, hash)
+		}
+	}
+
+	for _, value := range append([]string{mailFrom}, recipients...) {
+		if email := normalizeEmail(value); email != "" {
+			add(r.emailIndex(email))
+		}
+	}
+
+	for _, field := range fields {
+		if email := normalizeEmail(field.Value); email != "" {
+			add(r.emailIndex(email))
+			add(r.subjectIndex(email))
+		}
+		if strings.TrimSpace(field.Value) != "" && slices.ContainsFunc(searchable, func(name string) bool {
+			return strings.EqualFold(name, field.Name)
+		}) {
+			add(r.fieldIndex(field.Name, field.Value))
+		}
+	}
+
+	return index
+}
+
+// Reindex recomputes the blind index of every mail, batchSize mails at a time,
+// eg. for mails stored before they were indexed or after the searchable fields
+// of a form changed. Searchable holds the searchable fields per form.
+func (r *Repository) Reindex(ctx context.Context, batchSize int, searchable map[uuid.UUID][]string) (int, error) {
+	reindexed := 0
+
+	for afterID := int32(0); ; {
+		mails, err := r.db.SelectMailsAfterID(ctx, db.SelectMailsAfterIDParams{AfterID: afterID, Limit: int32(batchSize)})
+		if err != nil {
+			return reindexed, err
+		}
+		if len(mails) == 0 {
+			break
+		}
+
+		for _, mail := range mails {
+			decryptedMail, err := r.decryptMailWithoutContent(mail)
+			if err != nil {
+				return reindexed, err
+			}
+
+			// Recipients are stored as "<email> <name>"
+			recipients := make([]string, len(decryptedMail.Recipients))
+			for i, recipient := range decryptedMail.Recipients {
+				recipients[i], _, _ = strings.Cut(recipient, " ")
+			}
+
+			var fields []models.SubmissionField
+			if mail.SubmissionID.Valid {
+				submission, err := r.db.SelectSubmissionByID(ctx, mail.SubmissionID.Int32)
+				if err != nil {
+					return reindexed, err
+				}
+				if fields, err = r.decryptFields(submission.Fields); err != nil {
+					return reindexed, err
+				}
+			}
+
+			index := r.blindIndex(decryptedMail.MailFrom, recipients, fields, searchable[decryptedMail.FormID])
+			if slices.Equal(index, mail.BlindIndex) {
+				continue
+			}
+			if err := r.db.UpdateMailBlindIndex(ctx, db.UpdateMailBlindIndexParams{ID: mail.ID, BlindIndex: index}); err != nil {
+				return reindexed, err
+			}
+			reindexed++
+		}
+
+		afterID = mails[len(mails)-1].ID
+		logger.Logger.Info().Msgf("Reindexed %d mails, up to id %d", reindexed, afterID)
+	}
+
+	return reindexed, nil
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/sevaho/goforms/src/db"
+)
+
+// UseCaptchaChallenge marks a proof-of-work challenge as used, it returns false
+// when the challenge was used before. Challenges are kept till they expire.
+func (r *Repository) UseCaptchaChallenge(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	now := time.Now().UTC()
+	if err := r.db.DeleteExpiredCaptchaChallenges(ctx, db.TimeToPGTimestamp(now)); err != nil {
+		return false, err
+	}
+
+	count, err := r.db.UseCaptchaChallenge(ctx, db.UseCaptchaChallengeParams{
+		ID:        id,
+		ExpiresAt: db.TimeToPGTimestamp(expiresAt),
+	})
+	if err != nil {
+		return false, err
+	}
+	return count == 1, nil
+}
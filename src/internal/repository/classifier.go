@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/pkg/bayes"
+)
+
+var ErrInvalidLabel = errors.New("label should be either spam or ham")
+var ErrClassificationChanged = errors.New("mail was classified by someone else in the meantime, please try again")
+
+// ClassifyMail trains the spam classifier with the mail, when the mail was
+// labeled before the old label is unlearned first. The label is only set when
+// nobody classified the mail since it was read, and in the same transaction
+// as the training, so the classifier never counts a mail twice.
+func (r *Repository) ClassifyMail(ctx context.Context, id int, label string) error {
+	if label != models.LabelSpam && label != models.LabelHam {
+		return ErrInvalidLabel
+	}
+
+	mail, err := r.GetMailByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if mail.ClassifiedAs == label {
+		return nil
+	}
+
+	tokens := r.classifierTokens(mail.ContentPlainText)
+
+	return r.db.InTx(ctx, func(q db.Querier) error {
+		updated, err := q.SetMailClassification(ctx, db.SetMailClassificationParams{
+			ID:                   int32(id),
+			ClassifiedAs:         db.StringtoPGText(label),
+			PreviousClassifiedAs: pgtype.Text{String: mail.ClassifiedAs, Valid: mail.ClassifiedAs != ""},
+		})
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			return ErrClassificationChanged
+		}
+
+		if mail.ClassifiedAs != "" {
+			if err := r.trainClassifier(ctx, q, tokens, mail.ClassifiedAs, -1); err != nil {
+				return err
+			}
+		}
+
+		return r.trainClassifier(ctx, q, tokens, label, 1)
+	})
+}
+
+// SpamProbability returns the probability that the text is spam according to
+// what admins trained the classifier with so far, together with the amount of
+// spam and ham documents it was trained with.
+func (r *Repository) SpamProbability(ctx context.Context, text string) (float64, bayes.Counts, error) {
+	model := bayes.Model{Tokens: map[string]bayes.Counts{}}
+
+	documents, err := r.db.SelectClassifierDocuments(ctx)
+	if err != nil {
+		return 0, bayes.Counts{}, err
+	}
+
+	for _, d := range documents {
+		switch d.Label {
+		case models.LabelSpam:
+			model.Documents.Spam = int(d.Documents)
+		case models.LabelHam:
+			model.Documents.Ham = int(d.Documents)
+		}
+	}
+
+	if model.Documents.Spam == 0 || model.Documents.Ham == 0 {
+		return 0.5, model.Documents, nil
+	}
+
+	tokens := r.classifierTokens(text)
+	counts, err := r.db.SelectClassifierTokens(ctx, tokens)
+	if err != nil {
+		return 0, bayes.Counts{}, err
+	}
+
+	for _, c := range counts {
+		model.Tokens[c.Token] = bayes.Counts{Spam: int(c.Spam), Ham: int(c.Ham)}
+	}
+
+	return model.SpamProbability(tokens), model.Documents, nil
+}
+
+func (r *Repository) trainClassifier(ctx context.Context, q db.Querier, tokens []string, label string, delta int32) error {
+	params := db.UpdateClassifierTokensParams{Tokens: tokens}
+	if label == models.LabelSpam {
+		params.Spam = delta
+	} else {
+		params.Ham = delta
+	}
+
+	if err := q.UpdateClassifierTokens(ctx, params); err != nil {
+		return err
+	}
+
+	return q.UpdateClassifierDocuments(ctx, db.UpdateClassifierDocumentsParams{
+		Label:     label,
+		Documents: delta,
+	})
+}
+
+// Tokenizes the text and hashes the tokens, so the words of submissions are
+// not stored in plain text next to the encrypted mails.
+func (r *Repository) classifierTokens(text string) []string {
+	tokens := bayes.Tokenize(text)
+
+	hashed := make([]string, len(tokens))
+	for i, token := range tokens {
+		hashed[i] = r.Hash(token)
+	}
+	return hashed
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/sevaho/goforms/src/db"
+	"github.com/sevaho/goforms/src/internal/models"
+)
+
+// Fingerprint hashes the normalized fields of a submission, the order of the
+// fields, letter case and whitespace do not matter.
+func (r *Repository) Fingerprint(formID uuid.UUID, fields url.Values) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	mac := hmac.New(sha256.New, r.hashKey)
+	mac.Write(formID[:])
+	for _, key := range keys {
+		for _, value := range fields[key] {
+			value = strings.ToLower(strings.Join(strings.Fields(value), " "))
+			if value == "" {
+				continue
+			}
+			mac.Write([]byte(strings.ToLower(key) + "\x00" + value + "\x00"))
+		}
+	}
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Returns the last mail of the form with the same fingerprint within the window.
+func (r *Repository) GetDuplicateMail(ctx context.Context, formID uuid.UUID, fingerprint string, window time.Duration) (models.DecryptedMail, error) {
+	mail, err := r.db.SelectDuplicateMail(ctx, db.SelectDuplicateMailParams{
+		FormID:      db.UUIDToPGUUID(formID),
+		Fingerprint: db.StringtoPGText(fingerprint),
+		CreatedAt:   db.TimeToPGTimestamp(time.Now().UTC().Add(-window)),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.DecryptedMail{}, ErrMailNotFound
+		}
+		return models.DecryptedMail{}, err
+	}
+
+	return r.decryptMailWithoutContent(mail)
+}
+
+func (r *Repository) GetMailByIdempotencyKey(ctx context.Context, formID uuid.UUID, key string) (models.DecryptedMail, error) {
+	mail, err := r.db.SelectMailByIdempotencyKey(ctx, db.SelectMailByIdempotencyKeyParams{
+		FormID:         db.UUIDToPGUUID(formID),
+		IdempotencyKey: db.StringtoPGText(key),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.DecryptedMail{}, ErrMailNotFound
+		}
+		return models.DecryptedMail{}, err
+	}
+
+	return r.decryptMailWithoutContent(mail)
+}
+
+// Counts a suppressed duplicate submission on the original mail.
+func (r *Repository) CountDuplicate(ctx context.Context, id int32) error {
+	return r.db.IncrementMailDuplicates(ctx, db.IncrementMailDuplicatesParams{
+		ID:              id,
+		LastDuplicateAt: db.TimeToPGTimestamp(time.Now().UTC()),
+	})
+}
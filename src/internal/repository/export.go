@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/sevaho/goforms/src/internal/models"
+)
+
+// ExportMails calls write for every mail matching the filter, newest first,
+// with the fields of its submission. Mails are fetched batchSize at a time so
+// an export does not hold every mail in memory.
+func (r *Repository) ExportMails(ctx context.Context, filter models.MailFilter, batchSize int, write func(models.DecryptedMailWithContent) error) error {
+	var cursor *models.MailCursor
+
+	for {
+		params := r.searchMailsParams(filter, cursor)
+		params.Limit = int32(batchSize)
+
+		mails, err := r.db.SearchMails(ctx, params)
+		if err != nil {
+			return err
+		}
+		if len(mails) == 0 {
+			return nil
+		}
+
+		var submissionIDs []int32
+		for _, mail := range mails {
+			if mail.SubmissionID.Valid {
+				submissionIDs = append(submissionIDs, mail.SubmissionID.Int32)
+			}
+		}
+
+		submissions, err := r.db.SelectSubmissionsByID(ctx, submissionIDs)
+		if err != nil {
+			return err
+		}
+
+		fields := make(map[int32][]models.SubmissionField, len(submissions))
+		for _, submission := range submissions {
+			if fields[submission.ID], err = r.decryptFields(submission.Fields); err != nil {
+				return err
+			}
+		}
+
+		for _, mail := range mails {
+			decryptedMail, err := r.decryptMail(mail)
+			if err != nil {
+				return err
+			}
+			if mail.SubmissionID.Valid {
+				decryptedMail.Fields = fields[mail.SubmissionID.Int32]
+			}
+			if err := write(decryptedMail); err != nil {
+				return err
+			}
+		}
+
+		last := mails[len(mails)-1]
+		cursor = &models.MailCursor{CreatedAt: last.CreatedAt.Time, ID: last.ID}
+	}
+}
+
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/sevaho/goforms/src/db"
+	"github.com/sevaho/goforms/src/internal/models"
+	"github.com/sevaho/goforms/src/pkg/ipfilter"
+)
+
+// Returns the IP rules that did not expire yet.
+func (r *Repository) GetIPRules(ctx context.Context) ([]models.IPRule, error) {
+	rules, err := r.db.SelectActiveIPRules(ctx, db.TimeToPGTimestamp(time.Now().UTC()))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.IPRule, len(rules))
+	for i, rule := range rules {
+		result[i] = toIPRule(rule)
+	}
+	return result, nil
+}
+
+func (r *Repository) StoreIPRule(ctx context.Context, cidr string, action string, comment string, expiresAt *time.Time) (models.IPRule, error) {
+	params := db.InsertIPRuleParams{
+		CreatedAt: db.TimeToPGTimestamp(time.Now().UTC()),
+		Cidr:      cidr,
+		Action:    action,
+	}
+
+	if comment != "" {
+		params.Comment = db.StringtoPGText(comment)
+	}
+
+	if expiresAt != nil {
+		params.ExpiresAt = db.TimeToPGTimestamp(*expiresAt)
+	}
+
+	rule, err := r.db.InsertIPRule(ctx, params)
+	if err != nil {
+		return models.IPRule{}, err
+	}
+	return toIPRule(rule), nil
+}
+
+func (r *Repository) DeleteIPRule(ctx context.Context, id int) error {
+	count, err := r.db.DeleteIPRule(ctx, int32(id))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrIPRuleNotFound
+	}
+	return nil
+}
+
+// IsIPBlocked reports whether the IP address matches a block rule, an allow
+// rule always wins from a block rule so a single address can be exempted from
+// a blocked range.
+func (r *Repository) IsIPBlocked(ctx context.Context, ip string) (bool, error) {
+	rules, err := r.GetIPRules(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	blocked := false
+	for _, rule := range rules {
+		if !ipfilter.Contains(rule.CIDR, ip) {
+			continue
+		}
+		if rule.Action == models.IPRuleAllow {
+			return false, nil
+		}
+		blocked = true
+	}
+	return blocked, nil
+}
+
+func toIPRule(rule db.IpRule) models.IPRule {
+	result := models.IPRule{
+		ID:        rule.ID,
+		CreatedAt: rule.CreatedAt.Time,
+		CIDR:      rule.Cidr,
+		Action:    rule.Action,
+		Comment:   rule.Comment.String,
+	}
+
+	if rule.ExpiresAt.Valid {
+		expiresAt := rule.ExpiresAt.Time
+		result.ExpiresAt = &expiresAt
+	}
+	return result
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+	"github.com/sevaho/goforms/src/pkg/logger"
+)
+
+// Amount of rows that were re-encrypted with the primary key.
+type RotationResult struct {
+	Mails       int
+	Submissions int
+}
+
+// RotateKeys re-encrypts the mails and submissions that are not encrypted with
+// the primary key yet, batchSize rows at a time. Every row is updated on its
+// own, an interrupted rotation is resumed by running it again as rows that were
+// rotated already are skipped. A row is only updated when it still holds the
+// ciphertext that was re-encrypted, so a row that was erased in the meantime
+// does not get its data back.
+func (r *Repository) RotateKeys(ctx context.Context, batchSize int) (RotationResult, error) {
+	var result RotationResult
+
+	for afterID := int32(0); ; {
+		mails, err := r.db.SelectMailsAfterID(ctx, db.SelectMailsAfterIDParams{AfterID: afterID, Limit: int32(batchSize)})
+		if err != nil {
+			return result, err
+		}
+		if len(mails) == 0 {
+			break
+		}
+
+		for _, mail := range mails {
+			rotated, err := r.rotateMail(ctx, mail)
+			if err != nil {
+				return result, err
+			}
+			if rotated {
+				result.Mails++
+			}
+		}
+
+		afterID = mails[len(mails)-1].ID
+		logger.Logger.Info().Msgf("Rotated %d mails, up to id %d", result.Mails, afterID)
+	}
+
+	for afterID := int32(0); ; {
+		submissions, err := r.db.SelectSubmissionsAfterID(ctx, db.SelectSubmissionsAfterIDParams{AfterID: afterID, Limit: int32(batchSize)})
+		if err != nil {
+			return result, err
+		}
+		if len(submissions) == 0 {
+			break
+		}
+
+		for _, submission := range submissions {
+			fields, rotated, err := r.encryptor.Rotate(submission.Fields)
+			if err != nil {
+				return result, err
+			}
+			if !rotated {
+				continue
+			}
+			updated, err := r.db.UpdateSubmissionFields(ctx, db.UpdateSubmissionFieldsParams{ID: submission.ID, Fields: fields, PreviousFields: submission.Fields})
+			if err != nil {
+				return result, err
+			}
+			result.Submissions += int(updated)
+		}
+
+		afterID = submissions[len(submissions)-1].ID
+		logger.Logger.Info().Msgf("Rotated %d submissions, up to id %d", result.Submissions, afterID)
+	}
+
+	return result, nil
+}
+
+// Re-encrypts every encrypted column of the mail, returns whether it was
+// updated. A mail that changed since it was read is skipped.
+func (r *Repository) rotateMail(ctx context.Context, mail db.Mail) (bool, error) {
+	params := db.UpdateMailCiphertextsParams{
+		ID:              mail.ID,
+		Recipients:      make([]string, len(mail.Recipients)),
+		ClientIp:        mail.ClientIp,
+		UserAgent:       mail.UserAgent,
+		Origin:          mail.Origin,
+		PreviousContent: mail.Content,
+	}
+
+	columns := []struct {
+		value  string
+		target *string
+	}{
+		{mail.MailFrom, &params.MailFrom},
+		{mail.Subject, &params.Subject},
+		{mail.Content, &params.Content},
+	}
+	for i, recipient := range mail.Recipients {
+		columns = append(columns, struct {
+			value  string
+			target *string
+		}{recipient, &params.Recipients[i]})
+	}
+	for _, column := range []*pgtype.Text{&params.ClientIp, &params.UserAgent, &params.Origin} {
+		if column.Valid {
+			columns = append(columns, struct {
+				value  string
+				target *string
+			}{column.String, &column.String})
+		}
+	}
+
+	changed := false
+	for _, column := range columns {
+		rotated, ok, err := r.encryptor.Rotate(column.value)
+		if err != nil {
+			return false, err
+		}
+		*column.target = rotated
+		changed = changed || ok
+	}
+
+	if !changed {
+		return false, nil
+	}
+	updated, err := r.db.UpdateMailCiphertexts(ctx, params)
+	return updated > 0, err
+}
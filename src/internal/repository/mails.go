@@ -2,11 +2,17 @@ package repository
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/k3a/html2text"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/sevaho/goforms/src/db"
 	"github.com/sevaho/goforms/src/pkg/encryption"
 	"github.com/sevaho/goforms/src/pkg/logger"
@@ -14,41 +20,57 @@ import (
 )
 
 var ErrMailNotFound = errors.New("mail not found")
+var ErrIdempotencyKeyUsed = errors.New("idempotency key already used")
+var ErrIPRuleNotFound = errors.New("ip rule not found")
 
 type Repository struct {
 	encryptor *encryption.Encryptor
-	db        db.Querier
+	db        db.Store
+	// Key for hashing values that have to be looked up, eg. classifier tokens
+	hashKey []byte
 }
 
-func New(database db.Querier, encryptionKey string) *Repository {
-	encryptor, err := encryption.NewEncryptor(encryptionKey)
-	if err != nil {
-		panic("Failed to initialize encryptor: " + err.Error())
-	}
-	return &Repository{encryptor: encryptor, db: database}
+// Mails are encrypted with the encryptor, hashKey keys the hashes of values that
+// have to be looked up and should not change when the encryption key is rotated.
+func New(database db.Store, encryptor *encryption.Encryptor, hashKey string) *Repository {
+	mac := hmac.New(sha256.New, []byte(hashKey))
+	mac.Write([]byte("goforms hash key"))
+
+	return &Repository{encryptor: encryptor, db: database, hashKey: mac.Sum(nil)}
+}
+
+// Hash returns a keyed hash of the value, equal values give equal hashes but
+// the value can not be recovered without the secret key.
+func (r *Repository) Hash(value string) string {
+	mac := hmac.New(sha256.New, r.hashKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
 }
 
-func (r *Repository) GetMails(offset int, limit int) ([]models.DecryptedMail, int, error) {
+// Returns either the inbox or, when spam is true, the submissions tagged as
+// spam, with the content of every mail, eg. for the admin page.
+func (r *Repository) GetMailsWithContent(ctx context.Context, offset int, limit int, spam bool) ([]models.DecryptedMailWithContent, int, error) {
 	params := db.SelectAllMailsParams{
+		Spam:   spam,
 		Limit:  int32(limit),
 		Offset: int32(offset),
 	}
 
-	mails, err := r.db.SelectAllMails(context.Background(), params)
+	mails, err := r.db.SelectAllMails(ctx, params)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	decryptedMails := make([]models.DecryptedMail, len(mails))
+	decryptedMails := make([]models.DecryptedMailWithContent, len(mails))
 	for i, mail := range mails {
-		decryptedMail, err := r.decryptMailWithoutContent(mail)
+		decryptedMail, err := r.decryptMail(mail)
 		if err != nil {
 			return nil, 0, err
 		}
 		decryptedMails[i] = decryptedMail
 	}
 
-	count, err := r.db.CountAllMails(context.Background())
+	count, err := r.db.CountAllMails(ctx, spam)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -56,8 +78,8 @@ func (r *Repository) GetMails(offset int, limit int) ([]models.DecryptedMail, in
 	return decryptedMails, int(count), nil
 }
 
-func (r *Repository) GetMailByID(id int) (models.DecryptedMailWithContent, error) {
-	mail, err := r.db.SelectMailByID(context.Background(), int32(id))
+func (r *Repository) GetMailByID(ctx context.Context, id int) (models.DecryptedMailWithContent, error) {
+	mail, err := r.db.SelectMailByID(ctx, int32(id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.DecryptedMailWithContent{}, ErrMailNotFound
@@ -65,58 +87,113 @@ func (r *Repository) GetMailByID(id int) (models.DecryptedMailWithContent, error
 		return models.DecryptedMailWithContent{}, err
 	}
 
-	decryptedMail, err := r.decryptMailWithoutContent(mail)
+	decryptedMail, err := r.decryptMail(mail)
 	if err != nil {
 		return models.DecryptedMailWithContent{}, err
 	}
 
-	decryptedContent, err := r.encryptor.Decrypt(mail.Content)
-	if err != nil {
-		return models.DecryptedMailWithContent{}, err
+	if mail.SubmissionID.Valid {
+		submission, err := r.db.SelectSubmissionByID(ctx, mail.SubmissionID.Int32)
+		if err != nil {
+			return models.DecryptedMailWithContent{}, err
+		}
+
+		decryptedMail.Fields, err = r.decryptFields(submission.Fields)
+		if err != nil {
+			return models.DecryptedMailWithContent{}, err
+		}
 	}
 
-	return models.DecryptedMailWithContent{
-		DecryptedMail: decryptedMail,
-		Content:       decryptedContent,
-	}, nil
+	return decryptedMail, nil
 }
 
+// Store encrypts and stores the mail with the outcome of sending it, error is
+// the error of the mail provider. Returns the ID of the stored mail, or
+// ErrIdempotencyKeyUsed when a mail of the form has the same idempotency key.
 func (r *Repository) Store(
+	ctx context.Context,
+	mailProvider string,
+	subject string,
+	content string,
+	mail_from string,
+	recipients []models.Recipient,
+	meta models.SubmissionMeta,
+	error error,
+) (int32, error) {
+	return r.insertMail(ctx, mailProvider, subject, content, mail_from, recipients, meta, false, error)
+}
+
+// Reserve stores the mail before it is sent, it is pending until SetMailResult
+// records the outcome. Retries and duplicates find it in the meantime, the
+// idempotency key can only be reserved once.
+func (r *Repository) Reserve(
+	ctx context.Context,
+	mailProvider string,
+	subject string,
+	content string,
+	mail_from string,
+	recipients []models.Recipient,
+	meta models.SubmissionMeta,
+) (int32, error) {
+	return r.insertMail(ctx, mailProvider, subject, content, mail_from, recipients, meta, true, nil)
+}
+
+// Gives up on a reserved mail that was never sent, freeing its idempotency key
+// and fingerprint. Returns false when the mail is no longer pending.
+func (r *Repository) AbandonMail(ctx context.Context, id int32) (bool, error) {
+	abandoned, err := r.db.AbandonMail(ctx, db.AbandonMailParams{
+		ID:    id,
+		Error: db.StringtoPGText("Abandoned, the mail was still pending long after it was stored"),
+	})
+	return abandoned > 0, err
+}
+
+// Records the outcome of sending a reserved mail.
+func (r *Repository) SetMailResult(ctx context.Context, id int32, error error) error {
+	params := db.SetMailResultParams{ID: id, Success: error == nil}
+	if error != nil {
+		params.Error = db.StringtoPGText(error.Error())
+	}
+	return r.db.SetMailResult(ctx, params)
+}
+
+func (r *Repository) insertMail(
+	ctx context.Context,
 	mailProvider string,
 	subject string,
 	content string,
 	mail_from string,
 	recipients []models.Recipient,
+	meta models.SubmissionMeta,
+	pending bool,
 	error error,
-) {
+) (int32, error) {
 	encryptedSubject, err := r.encryptor.Encrypt(subject)
 	if err != nil {
-		logger.Logger.Error().Err(err).Msg("Failed to encrypt subject")
-		panic(err)
+		return 0, fmt.Errorf("Failed to encrypt subject: %w", err)
 	}
 
 	encryptedContent, err := r.encryptor.Encrypt(content)
 	if err != nil {
-		logger.Logger.Error().Err(err).Msg("Failed to encrypt content")
-		panic(err)
+		return 0, fmt.Errorf("Failed to encrypt content: %w", err)
 	}
 
 	encryptedMailFrom, err := r.encryptor.Encrypt(mail_from)
 	if err != nil {
-		logger.Logger.Error().Err(err).Msg("Failed to encrypt mail_from")
-		panic(err)
+		return 0, fmt.Errorf("Failed to encrypt mail_from: %w", err)
 	}
 
 	recipient_as_string := []string{}
+	recipient_emails := []string{}
 
 	for _, recepient := range recipients {
 		recipient_as_string = append(recipient_as_string, recepient.Email+" "+recepient.Name)
+		recipient_emails = append(recipient_emails, recepient.Email)
 	}
 
 	encryptedRecipients, err := r.encryptor.EncryptStringSlice(recipient_as_string)
 	if err != nil {
-		logger.Logger.Error().Err(err).Msg("Failed to encrypt recipients")
-		panic(err)
+		return 0, fmt.Errorf("Failed to encrypt recipients: %w", err)
 	}
 
 	params := db.InsertMailParams{
@@ -126,21 +203,95 @@ func (r *Repository) Store(
 		MailFrom:     encryptedMailFrom,
 		MailProvider: mailProvider,
 		Recipients:   encryptedRecipients,
+		FormID:       db.UUIDToPGUUID(meta.FormID),
+		Spam:         meta.Spam.Spam,
+		SpamScore:    meta.Spam.Score,
+		SpamReasons:  meta.Spam.Reasons,
+		BlindIndex:   r.blindIndex(mail_from, recipient_emails, meta.Fields, meta.Searchable),
+	}
+
+	if meta.Fingerprint != "" {
+		params.Fingerprint = db.StringtoPGText(meta.Fingerprint)
+	}
+
+	if meta.IdempotencyKey != "" {
+		params.IdempotencyKey = db.StringtoPGText(meta.IdempotencyKey)
+	}
+
+	// Only the metadata the privacy settings of the form allow is passed
+	for _, column := range []struct {
+		value  string
+		target *pgtype.Text
+	}{
+		{meta.Metadata.IP, &params.ClientIp},
+		{meta.Metadata.UserAgent, &params.UserAgent},
+		{meta.Metadata.Origin, &params.Origin},
+	} {
+		if column.value == "" {
+			continue
+		}
+		encrypted, err := r.encryptor.Encrypt(column.value)
+		if err != nil {
+			return 0, fmt.Errorf("Failed to encrypt metadata: %w", err)
+		}
+		*column.target = db.StringtoPGText(encrypted)
+	}
+
+	if meta.Metadata.Language != "" {
+		params.Language = db.StringtoPGText(meta.Metadata.Language)
+	}
+
+	if meta.Metadata.Country != "" {
+		params.Country = db.StringtoPGText(meta.Metadata.Country)
+	}
+
+	if meta.Metadata.RequestID != "" {
+		params.RequestID = db.StringtoPGText(meta.Metadata.RequestID)
+	}
+
+	if meta.SubmissionID != nil {
+		params.SubmissionID = pgtype.Int4{Int32: *meta.SubmissionID, Valid: true}
+	}
+
+	if meta.CaptchaScore != nil {
+		params.CaptchaScore = pgtype.Float8{Float64: *meta.CaptchaScore, Valid: true}
+	}
+
+	if params.SpamReasons == nil {
+		params.SpamReasons = []string{}
 	}
 
 	if error != nil {
 		params.Error = db.StringtoPGText(error.Error())
 		params.Success = false
 	} else {
-		params.Success = true
+		// Spam is stored but never sent
+		params.Success = !meta.Spam.Spam && !pending
 	}
 
-	id, err := r.db.InsertMail(context.Background(), params)
-
+	id, err := r.db.InsertMail(ctx, params)
 	if err != nil {
-		panic(err)
+		// Postgres reports a unique violation with this code, the other stores do the same
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, ErrIdempotencyKeyUsed
+		}
+		return 0, err
 	}
+
 	logger.Logger.Info().Msgf("Mail stored with id: %d", id)
+	return id, nil
+}
+
+// Takes a mail that was tagged as spam out of the spam so it can be sent,
+// returns false when it is not spam (anymore). Only one of concurrent releases
+// gets true, the outcome of sending is recorded with SetMailResult.
+func (r *Repository) ReleaseMail(ctx context.Context, id int) (bool, error) {
+	released, err := r.db.ReleaseMail(ctx, db.ReleaseMailParams{
+		ID:         int32(id),
+		ReleasedAt: db.TimeToPGTimestamp(time.Now().UTC()),
+	})
+	return released > 0, err
 }
 
 func (r *Repository) decryptMailWithoutContent(mail db.Mail) (models.DecryptedMail, error) {
@@ -154,22 +305,180 @@ func (r *Repository) decryptMailWithoutContent(mail db.Mail) (models.DecryptedMa
 		return models.DecryptedMail{}, err
 	}
 
-	mailFromPlanText := html2text.HTML2Text(decryptedMailFrom)
-
 	decryptedRecipients, err := r.encryptor.DecryptStringSlice(mail.Recipients)
 	if err != nil {
 		return models.DecryptedMail{}, err
 	}
 
-	return models.DecryptedMail{
+	decryptedMail := models.DecryptedMail{
 		ID:           mail.ID,
 		CreatedAt:    mail.CreatedAt.Time,
 		MailProvider: mail.MailProvider,
 		Success:      mail.Success,
 		MailFrom:     decryptedMailFrom,
-		MailFromPlainText:			mailFromPlanText,
 		Recipients:   decryptedRecipients,
 		Subject:      decryptedSubject,
 		Error:        mail.Error.String,
+		FormID:       mail.FormID.Bytes,
+		Spam:         mail.Spam,
+		SpamScore:    mail.SpamScore,
+		SpamReasons:  mail.SpamReasons,
+		ClassifiedAs: mail.ClassifiedAs.String,
+		Duplicates:   mail.Duplicates,
+	}
+
+	if mail.LastDuplicateAt.Valid {
+		lastDuplicateAt := mail.LastDuplicateAt.Time
+		decryptedMail.LastDuplicateAt = &lastDuplicateAt
+	}
+
+	if mail.ReleasedAt.Valid {
+		releasedAt := mail.ReleasedAt.Time
+		decryptedMail.ReleasedAt = &releasedAt
+	}
+
+	if mail.CaptchaScore.Valid {
+		captchaScore := mail.CaptchaScore.Float64
+		decryptedMail.CaptchaScore = &captchaScore
+	}
+
+	if mail.SubmissionID.Valid {
+		submissionID := mail.SubmissionID.Int32
+		decryptedMail.SubmissionID = &submissionID
+	}
+
+	decryptedMail.Metadata = models.Metadata{
+		Language:  mail.Language.String,
+		Country:   mail.Country.String,
+		RequestID: mail.RequestID.String,
+	}
+
+	for _, column := range []struct {
+		value  pgtype.Text
+		target *string
+	}{
+		{mail.ClientIp, &decryptedMail.Metadata.IP},
+		{mail.UserAgent, &decryptedMail.Metadata.UserAgent},
+		{mail.Origin, &decryptedMail.Metadata.Origin},
+	} {
+		if !column.value.Valid {
+			continue
+		}
+		if *column.target, err = r.encryptor.Decrypt(column.value.String); err != nil {
+			return models.DecryptedMail{}, err
+		}
+	}
+
+	return decryptedMail, nil
+}
+
+func (r *Repository) decryptMail(mail db.Mail) (models.DecryptedMailWithContent, error) {
+	decryptedMail, err := r.decryptMailWithoutContent(mail)
+	if err != nil {
+		return models.DecryptedMailWithContent{}, err
+	}
+
+	decryptedContent, err := r.encryptor.Decrypt(mail.Content)
+	if err != nil {
+		return models.DecryptedMailWithContent{}, err
+	}
+
+	return models.DecryptedMailWithContent{
+		DecryptedMail:    decryptedMail,
+		Content:          decryptedContent,
+		ContentPlainText: html2text.HTML2Text(decryptedContent),
 	}, nil
 }
+
+// Translates the filter and the cursor to the params of the search query.
+func (r *Repository) searchMailsParams(filter models.MailFilter, cursor *models.MailCursor) db.SearchMailsParams {
+	var params db.SearchMailsParams
+
+	if filter.CreatedAfter != nil {
+		params.CreatedAfter = db.TimeToPGTimestamp(*filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		params.CreatedBefore = db.TimeToPGTimestamp(*filter.CreatedBefore)
+	}
+	if filter.FormID != nil {
+		params.FormID = db.UUIDToPGUUID(*filter.FormID)
+	}
+	if filter.Provider != "" {
+		params.MailProvider = db.StringtoPGText(filter.Provider)
+	}
+	if filter.Success != nil {
+		params.Success = db.BooltoPGBool(*filter.Success)
+	}
+	if filter.Spam != nil {
+		params.Spam = db.BooltoPGBool(*filter.Spam)
+	}
+	if filter.Email != "" {
+		params.BlindIndex = db.StringtoPGText(r.emailIndex(normalizeEmail(filter.Email)))
+	} else if filter.Field != "" {
+		params.BlindIndex = db.StringtoPGText(r.fieldIndex(filter.Field, filter.Value))
+	}
+	if cursor != nil {
+		params.CursorCreatedAt = db.TimeToPGTimestamp(cursor.CreatedAt)
+		params.CursorID = pgtype.Int4{Int32: cursor.ID, Valid: true}
+	}
+
+	return params
+}
+
+// CountMails counts the mails matching the filter.
+func (r *Repository) CountMails(ctx context.Context, filter models.MailFilter) (int, error) {
+	params := r.searchMailsParams(filter, nil)
+	count, err := r.db.CountSearchMails(ctx, db.CountSearchMailsParams{
+		CreatedAfter:  params.CreatedAfter,
+		CreatedBefore: params.CreatedBefore,
+		FormID:        params.FormID,
+		MailProvider:  params.MailProvider,
+		Success:       params.Success,
+		Spam:          params.Spam,
+		BlindIndex:    params.BlindIndex,
+	})
+	return int(count), err
+}
+
+// SearchMails returns a page of the mails matching the filter, newest first,
+// starting after the cursor when there is one. The next cursor is nil on the
+// last page. Counting is slow on large tables, so the mails are only counted
+// for the first page.
+func (r *Repository) SearchMails(ctx context.Context, filter models.MailFilter, cursor *models.MailCursor, offset int, limit int) ([]models.DecryptedMail, *int, *models.MailCursor, error) {
+	params := r.searchMailsParams(filter, cursor)
+	params.Limit = int32(limit + 1)
+	params.Offset = int32(offset)
+
+	// One mail more than asked tells whether there is a next page
+	mails, err := r.db.SearchMails(ctx, params)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var next *models.MailCursor
+	if len(mails) > limit {
+		mails = mails[:limit]
+		last := mails[len(mails)-1]
+		next = &models.MailCursor{CreatedAt: last.CreatedAt.Time, ID: last.ID}
+	}
+
+	decryptedMails := make([]models.DecryptedMail, len(mails))
+	for i, mail := range mails {
+		decryptedMail, err := r.decryptMailWithoutContent(mail)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		decryptedMails[i] = decryptedMail
+	}
+
+	if cursor != nil {
+		return decryptedMails, nil, next, nil
+	}
+
+	total, err := r.CountMails(ctx, filter)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return decryptedMails, &total, next, nil
+}
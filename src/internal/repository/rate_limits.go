@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/sevaho/goforms/src/db"
+	"github.com/sevaho/goforms/src/internal/models"
+)
+
+// TakeRateLimitToken takes a token from the bucket, the bucket lives in the
+// database so all replicas share it. When the bucket is empty it returns false
+// and how long the client has to wait for the next token.
+func (r *Repository) TakeRateLimitToken(ctx context.Context, bucket string, limit models.RateLimit) (bool, time.Duration, error) {
+	refillPerSecond := limit.PerMinute / 60
+
+	row, err := r.db.TakeRateLimitToken(ctx, db.TakeRateLimitTokenParams{
+		Bucket:          bucket,
+		Capacity:        float64(limit.Burst),
+		Now:             db.TimeToPGTimestamp(time.Now().UTC()),
+		RefillPerSecond: refillPerSecond,
+	})
+	if err != nil {
+		return false, 0, err
+	}
+
+	if row.Allowed {
+		return true, 0, nil
+	}
+
+	retryAfter := time.Duration((1 - row.Tokens) / refillPerSecond * float64(time.Second))
+	return false, retryAfter, nil
+}
+
+// Deletes the buckets that were not used for refillTime, they are full again
+// and a new bucket starts out full anyway. Returns the amount of deleted buckets.
+func (r *Repository) PruneRateLimits(ctx context.Context, refillTime time.Duration) (int64, error) {
+	return r.db.DeleteFullRateLimits(ctx, db.TimeToPGTimestamp(time.Now().UTC().Add(-refillTime)))
+}
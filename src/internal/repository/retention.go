@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+	"github.com/sevaho/goforms/src/internal/models"
+)
+
+// Mails and submissions created before the cutoff are purged, a retention of 0
+// gives a cutoff nothing is created before.
+func cutoff(now time.Time, retention time.Duration) pgtype.Timestamp {
+	if retention <= 0 {
+		return pgtype.Timestamp{InfinityModifier: pgtype.NegativeInfinity, Valid: true}
+	}
+	return db.TimeToPGTimestamp(now.Add(-retention))
+}
+
+var ErrPurgeRunning = errors.New("another purge is running")
+
+// Key of the advisory lock that makes sure only one replica purges at a time.
+const purgeLockKey int64 = 0x676f666f726d73 // "goforms"
+
+// Purge deletes the mails and submissions that are older than their retention,
+// or only counts them on a dry run. Every run is logged in purge_runs. Replicas
+// purge one at a time, a run that finds another one busy returns
+// ErrPurgeRunning without deleting or logging anything.
+func (r *Repository) Purge(ctx context.Context, retention models.Retention, dryRun bool) (models.PurgeRun, error) {
+	run := models.PurgeRun{StartedAt: time.Now().UTC(), DryRun: dryRun}
+
+	var formIDs []pgtype.UUID
+	var cutoffs []pgtype.Timestamp
+	for formID, formRetention := range retention.Forms {
+		formIDs = append(formIDs, db.UUIDToPGUUID(formID))
+		cutoffs = append(cutoffs, cutoff(run.StartedAt, formRetention))
+	}
+	defaultCutoff := cutoff(run.StartedAt, retention.Default)
+
+	var err error
+	if dryRun {
+		run.Mails, err = r.db.CountExpiredMails(ctx, db.CountExpiredMailsParams{FormIds: formIDs, Cutoffs: cutoffs, DefaultCutoff: defaultCutoff})
+		if err == nil {
+			run.Submissions, err = r.db.CountExpiredSubmissions(ctx, db.CountExpiredSubmissionsParams{FormIds: formIDs, Cutoffs: cutoffs, DefaultCutoff: defaultCutoff})
+		}
+	} else {
+		err = r.db.InTx(ctx, func(q db.Querier) error {
+			locked, err := q.TryAdvisoryXactLock(ctx, purgeLockKey)
+			if err != nil {
+				return err
+			}
+			if !locked {
+				return ErrPurgeRunning
+			}
+
+			run.Mails, err = q.DeleteExpiredMails(ctx, db.DeleteExpiredMailsParams{
+				FormIds:        formIDs,
+				Cutoffs:        cutoffs,
+				DefaultCutoff:  defaultCutoff,
+				KeepStatistics: retention.KeepStatistics,
+			})
+			if err != nil {
+				return err
+			}
+			run.Submissions, err = q.DeleteExpiredSubmissions(ctx, db.DeleteExpiredSubmissionsParams{FormIds: formIDs, Cutoffs: cutoffs, DefaultCutoff: defaultCutoff})
+			return err
+		})
+		if errors.Is(err, ErrPurgeRunning) {
+			return run, err
+		}
+		if err != nil {
+			// Rolled back, nothing was deleted
+			run.Mails, run.Submissions = 0, 0
+		}
+	}
+
+	run.FinishedAt = time.Now().UTC()
+	params := db.InsertPurgeRunParams{
+		StartedAt:   db.TimeToPGTimestamp(run.StartedAt),
+		FinishedAt:  db.TimeToPGTimestamp(run.FinishedAt),
+		DryRun:      run.DryRun,
+		Mails:       run.Mails,
+		Submissions: run.Submissions,
+	}
+	if err != nil {
+		run.Error = err.Error()
+		params.Error = db.StringtoPGText(run.Error)
+	}
+
+	logged, logErr := r.db.InsertPurgeRun(ctx, params)
+	if logErr != nil {
+		if err == nil {
+			err = logErr
+		}
+		return run, err
+	}
+
+	run.ID = logged.ID
+	return run, err
+}
+
+// Returns the last purge runs, newest first.
+func (r *Repository) GetPurgeRuns(ctx context.Context, limit int) ([]models.PurgeRun, error) {
+	rows, err := r.db.SelectPurgeRuns(ctx, int32(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]models.PurgeRun, len(rows))
+	for i, row := range rows {
+		runs[i] = models.PurgeRun{
+			ID:          row.ID,
+			StartedAt:   row.StartedAt.Time,
+			FinishedAt:  row.FinishedAt.Time,
+			DryRun:      row.DryRun,
+			Mails:       row.Mails,
+			Submissions: row.Submissions,
+			Error:       row.Error.String,
+		}
+	}
+	return runs, nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+	"github.com/sevaho/goforms/src/internal/models"
+)
+
+// Amount of origins in the statistics, the ones with the most mails.
+const topOrigins = 10
+
+// GetStats counts the mails in the time range per bucket and form, the
+// delivery per provider, the spam ratio and the origins with the most mails.
+// Origins are encrypted so they are counted here after decryption, full
+// origins count by their host.
+func (r *Repository) GetStats(ctx context.Context, filter models.StatsFilter) (models.Stats, error) {
+	stats := models.Stats{
+		From:      filter.From,
+		To:        filter.To,
+		Bucket:    filter.Bucket,
+		Forms:     []models.FormStats{},
+		Providers: []models.ProviderStats{},
+		Origins:   []models.OriginStats{},
+	}
+	createdAfter, createdBefore := db.TimeToPGTimestamp(filter.From), db.TimeToPGTimestamp(filter.To)
+
+	rows, err := r.db.CountMailsByBucket(ctx, db.CountMailsByBucketParams{Bucket: filter.Bucket, CreatedAfter: createdAfter, CreatedBefore: createdBefore})
+	if err != nil {
+		return stats, err
+	}
+
+	purged, err := r.db.CountPurgedMailsByBucket(ctx, db.CountPurgedMailsByBucketParams{Bucket: filter.Bucket, CreatedAfter: createdAfter, CreatedBefore: createdBefore})
+	if err != nil {
+		return stats, err
+	}
+
+	origins, err := r.db.SelectMailOrigins(ctx, db.SelectMailOriginsParams{CreatedAfter: createdAfter, CreatedBefore: createdBefore})
+	if err != nil {
+		return stats, err
+	}
+
+	// Mails without a form and purged mails without a form both have the nil UUID
+	type formKey struct {
+		bucket time.Time
+		formID uuid.UUID
+	}
+	forms := map[formKey]*models.FormStats{}
+	countForm := func(bucket pgtype.Timestamp, formID pgtype.UUID, mails int64, spam int64, success int64) {
+		key := formKey{bucket.Time.UTC(), uuid.UUID(formID.Bytes)}
+		form, ok := forms[key]
+		if !ok {
+			form = &models.FormStats{Bucket: key.bucket, FormID: key.formID}
+			forms[key] = form
+		}
+		form.Mails += mails
+		form.Spam += spam
+		form.Success += success
+
+		stats.Spam.Mails += mails
+		stats.Spam.Spam += spam
+	}
+
+	providers := map[string]*models.ProviderStats{}
+	for _, row := range rows {
+		countForm(row.Bucket, row.FormID, row.Mails, row.Spam, row.Success)
+
+		// Spam is held instead of sent, it is not a delivery of the provider
+		if row.Mails == row.Spam {
+			continue
+		}
+		provider, ok := providers[row.MailProvider]
+		if !ok {
+			provider = &models.ProviderStats{Provider: row.MailProvider}
+			providers[row.MailProvider] = provider
+		}
+		provider.Mails += row.Mails - row.Spam
+		provider.Success += row.Success
+	}
+
+	for _, row := range purged {
+		countForm(row.Bucket, row.FormID, row.Mails, row.Spam, row.Success)
+	}
+
+	for _, form := range forms {
+		stats.Forms = append(stats.Forms, *form)
+	}
+	slices.SortFunc(stats.Forms, func(a, b models.FormStats) int {
+		if c := a.Bucket.Compare(b.Bucket); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.FormID.String(), b.FormID.String())
+	})
+
+	for _, provider := range providers {
+		provider.SuccessRate = float64(provider.Success) / float64(provider.Mails)
+		stats.Providers = append(stats.Providers, *provider)
+	}
+	slices.SortFunc(stats.Providers, func(a, b models.ProviderStats) int { return cmp.Compare(a.Provider, b.Provider) })
+
+	if stats.Spam.Mails > 0 {
+		stats.Spam.Ratio = float64(stats.Spam.Spam) / float64(stats.Spam.Mails)
+	}
+
+	counts := map[string]int64{}
+	for _, encrypted := range origins {
+		origin, err := r.encryptor.Decrypt(encrypted.String)
+		if err != nil {
+			return stats, err
+		}
+		if u, err := url.Parse(origin); err == nil && u.Host != "" {
+			origin = u.Host
+		}
+		if origin != "" {
+			counts[origin]++
+		}
+	}
+
+	for origin, mails := range counts {
+		stats.Origins = append(stats.Origins, models.OriginStats{Origin: origin, Mails: mails})
+	}
+	slices.SortFunc(stats.Origins, func(a, b models.OriginStats) int {
+		if c := cmp.Compare(b.Mails, a.Mails); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Origin, b.Origin)
+	})
+	if len(stats.Origins) > topOrigins {
+		stats.Origins = stats.Origins[:topOrigins]
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/sevaho/goforms/src/db"
+	"github.com/sevaho/goforms/src/internal/models"
+)
+
+// Data subject requests find the mails an email address was submitted in
+// through the blind index, every request is recorded in subject_requests with
+// the hash of the address.
+
+func (r *Repository) subjectMails(ctx context.Context, q db.Querier, email string) ([]db.Mail, []int32, error) {
+	mails, err := q.SelectSubjectMails(ctx, r.subjectIndex(normalizeEmail(email)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var submissionIDs []int32
+	for _, mail := range mails {
+		if mail.SubmissionID.Valid && !slices.Contains(submissionIDs, mail.SubmissionID.Int32) {
+			submissionIDs = append(submissionIDs, mail.SubmissionID.Int32)
+		}
+	}
+	return mails, submissionIDs, nil
+}
+
+func (r *Repository) recordSubjectRequest(ctx context.Context, q db.Querier, action string, email string, mails int64, submissions int64) error {
+	return q.InsertSubjectRequest(ctx, db.InsertSubjectRequestParams{
+		CreatedAt:   db.TimeToPGTimestamp(time.Now().UTC()),
+		Action:      action,
+		Subject:     r.subjectIndex(normalizeEmail(email)),
+		Mails:       mails,
+		Submissions: submissions,
+	})
+}
+
+// ExportSubject returns the mails and submissions an email address is in.
+func (r *Repository) ExportSubject(ctx context.Context, email string) (models.SubjectExport, error) {
+	export := models.SubjectExport{
+		Email:       normalizeEmail(email),
+		ExportedAt:  time.Now().UTC(),
+		Mails:       []models.DecryptedMailWithContent{},
+		Submissions: []models.Submission{},
+	}
+
+	mails, submissionIDs, err := r.subjectMails(ctx, r.db, email)
+	if err != nil {
+		return export, err
+	}
+
+	for _, mail := range mails {
+		decryptedMail, err := r.decryptMail(mail)
+		if err != nil {
+			return export, err
+		}
+		export.Mails = append(export.Mails, decryptedMail)
+	}
+
+	for _, id := range submissionIDs {
+		submission, err := r.GetSubmission(ctx, int(id))
+		if err != nil {
+			return export, err
+		}
+		export.Submissions = append(export.Submissions, submission)
+	}
+
+	return export, r.recordSubjectRequest(ctx, r.db, "export", email, int64(len(export.Mails)), int64(len(export.Submissions)))
+}
+
+// EraseSubject deletes or shreds the mails an email address is in, the
+// submissions of those mails are deleted either way. All of it happens in one
+// transaction with the record of the request.
+func (r *Repository) EraseSubject(ctx context.Context, email string, mode string) (models.SubjectErasure, error) {
+	erasure := models.SubjectErasure{Mode: mode}
+
+	err := r.db.InTx(ctx, func(q db.Querier) error {
+		mails, submissionIDs, err := r.subjectMails(ctx, q, email)
+		if err != nil {
+			return err
+		}
+
+		mailIDs := make([]int32, len(mails))
+		for i, mail := range mails {
+			mailIDs[i] = mail.ID
+		}
+		erasure.MailIDs, erasure.SubmissionIDs = mailIDs, submissionIDs
+
+		if mode == models.ErasureShred {
+			erasure.Mails, err = q.ShredMailsByID(ctx, mailIDs)
+		} else {
+			erasure.Mails, err = q.DeleteMailsByID(ctx, mailIDs)
+		}
+		if err != nil {
+			return err
+		}
+
+		erasure.Submissions, err = q.DeleteSubmissionsByID(ctx, submissionIDs)
+		if err != nil {
+			return err
+		}
+
+		return r.recordSubjectRequest(ctx, q, mode, email, erasure.Mails, erasure.Submissions)
+	})
+	return erasure, err
+}
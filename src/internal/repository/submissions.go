@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sevaho/goforms/src/db"
+	"github.com/sevaho/goforms/src/internal/models"
+)
+
+var ErrSubmissionNotFound = errors.New("submission not found")
+
+// StoreSubmission stores the submitted fields encrypted, in the order they were posted.
+func (r *Repository) StoreSubmission(ctx context.Context, formID uuid.UUID, fields []models.SubmissionField) (int32, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return 0, err
+	}
+
+	encryptedFields, err := r.encryptor.Encrypt(string(data))
+	if err != nil {
+		return 0, err
+	}
+
+	return r.db.InsertSubmission(ctx, db.InsertSubmissionParams{
+		CreatedAt: db.TimeToPGTimestamp(time.Now().UTC()),
+		FormID:    db.UUIDToPGUUID(formID),
+		Fields:    encryptedFields,
+	})
+}
+
+func (r *Repository) GetSubmission(ctx context.Context, id int) (models.Submission, error) {
+	submission, err := r.db.SelectSubmissionByID(ctx, int32(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Submission{}, ErrSubmissionNotFound
+		}
+		return models.Submission{}, err
+	}
+
+	fields, err := r.decryptFields(submission.Fields)
+	if err != nil {
+		return models.Submission{}, err
+	}
+
+	mailIDs, err := r.db.SelectMailIDsBySubmissionID(ctx, pgtype.Int4{Int32: submission.ID, Valid: true})
+	if err != nil {
+		return models.Submission{}, err
+	}
+
+	return models.Submission{
+		ID:        submission.ID,
+		CreatedAt: submission.CreatedAt.Time,
+		FormID:    submission.FormID.Bytes,
+		Fields:    fields,
+		MailIDs:   mailIDs,
+	}, nil
+}
+
+func (r *Repository) decryptFields(encryptedFields string) ([]models.SubmissionField, error) {
+	data, err := r.encryptor.Decrypt(encryptedFields)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []models.SubmissionField
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,73 @@
+// Package bayes is a small naive Bayes text classifier. It works on the
+// presence of tokens per document, the counts are kept by the caller.
+package bayes
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	minTokenLength = 3
+	maxTokenLength = 32
+)
+
+// Counts in how many spam and ham documents something occurred.
+type Counts struct {
+	Spam int
+	Ham  int
+}
+
+type Model struct {
+	Documents Counts
+	Tokens    map[string]Counts
+}
+
+// Tokenize lowercases the text and splits it into unique words, words that are
+// too short or too long to tell anything are dropped.
+func Tokenize(text string) []string {
+	seen := map[string]bool{}
+	tokens := []string{}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsNumber(c) && c != '\'' && c != '-'
+	})
+
+	for _, word := range words {
+		word = strings.Trim(word, "'-")
+		length := utf8.RuneCountInString(word)
+		if length < minTokenLength || length > maxTokenLength || seen[word] {
+			continue
+		}
+		seen[word] = true
+		tokens = append(tokens, word)
+	}
+
+	return tokens
+}
+
+// SpamProbability returns the probability that a document with these tokens is
+// spam. Tokens the model never saw are ignored, the counts are smoothed so a
+// single occurrence can not decide on its own.
+func (m Model) SpamProbability(tokens []string) float64 {
+	spamDocuments, hamDocuments := float64(m.Documents.Spam), float64(m.Documents.Ham)
+	if spamDocuments == 0 || hamDocuments == 0 {
+		return 0.5
+	}
+
+	logSpam := math.Log(spamDocuments / (spamDocuments + hamDocuments))
+	logHam := math.Log(hamDocuments / (spamDocuments + hamDocuments))
+
+	for _, token := range tokens {
+		counts, ok := m.Tokens[token]
+		if !ok || (counts.Spam == 0 && counts.Ham == 0) {
+			continue
+		}
+		logSpam += math.Log((float64(counts.Spam) + 1) / (spamDocuments + 2))
+		logHam += math.Log((float64(counts.Ham) + 1) / (hamDocuments + 2))
+	}
+
+	return 1 / (1 + math.Exp(logHam-logSpam))
+}
@@ -0,0 +1,152 @@
+package captcha
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Built-in captcha that does not depend on a third party. The client has to find
+// a counter so the SHA-256 hash of "<challenge>:<counter>" starts with Difficulty
+// zero bits, the widget puts "<challenge>:<counter>" in the response field.
+var ProofOfWorkProvider = Provider{
+	Name:          "pow",
+	ResponseField: "pow-captcha-response",
+	Script:        "/static/js/pow.js",
+	WidgetClass:   "pow-captcha",
+}
+
+const (
+	DefaultDifficulty = 16
+	challengeTTL      = 10 * time.Minute
+)
+
+var ErrReplay = errors.New("Captcha was used already.")
+
+// Remembers used challenges till they expire, returns false for a challenge that
+// was used before.
+type ReplayStore interface {
+	UseCaptchaChallenge(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+}
+
+type ProofOfWork struct {
+	key        []byte
+	scope      string
+	difficulty int
+	store      ReplayStore
+}
+
+// Challenges are signed with key and only valid for the given scope, eg. the form ID.
+func NewProofOfWork(key []byte, scope string, difficulty int, store ReplayStore) *ProofOfWork {
+	if difficulty <= 0 {
+		difficulty = DefaultDifficulty
+	}
+	return &ProofOfWork{key: key, scope: scope, difficulty: difficulty, store: store}
+}
+
+func (p *ProofOfWork) Difficulty() int {
+	return p.difficulty
+}
+
+func (p *ProofOfWork) ResponseField() string {
+	return ProofOfWorkProvider.ResponseField
+}
+
+// Issues a signed challenge, the server does not have to remember it.
+func (p *ProofOfWork) Challenge() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	payload := strings.Join([]string{
+		p.scope,
+		strconv.Itoa(p.difficulty),
+		strconv.FormatInt(time.Now().Add(challengeTTL).Unix(), 10),
+		hex.EncodeToString(nonce),
+	}, "|")
+
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(p.sign(payload)), nil
+}
+
+func (p *ProofOfWork) Verify(ctx context.Context, remoteIP string, response string) (Result, error) {
+	var result Result
+
+	challenge, counter, found := strings.Cut(response, ":")
+	if !found {
+		return result, ErrNoResponse
+	}
+
+	encodedPayload, encodedSignature, found := strings.Cut(challenge, ".")
+	if !found {
+		return result, ErrNoResponse
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return result, ErrNoResponse
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, p.sign(string(payload))) {
+		return result, ErrNoResponse
+	}
+
+	fields := strings.Split(string(payload), "|")
+	if len(fields) != 4 || fields[0] != p.scope {
+		return result, ErrNoResponse
+	}
+
+	difficulty, err := strconv.Atoi(fields[1])
+	if err != nil || difficulty < p.difficulty {
+		return result, ErrNoResponse
+	}
+
+	expires, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return result, fmt.Errorf("%w (expired)", ErrNoResponse)
+	}
+
+	hash := sha256.Sum256([]byte(challenge + ":" + counter))
+	if leadingZeroBits(hash[:]) < difficulty {
+		return result, ErrNoResponse
+	}
+
+	fresh, err := p.store.UseCaptchaChallenge(ctx, fields[3], time.Unix(expires, 0))
+	if err != nil {
+		return result, err
+	}
+	if !fresh {
+		return result, ErrReplay
+	}
+
+	result.Success = true
+	result.ChallengeTS = time.Unix(expires, 0).Add(-challengeTTL)
+	return result, nil
+}
+
+func (p *ProofOfWork) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func leadingZeroBits(hash []byte) int {
+	n := 0
+	for _, b := range hash {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
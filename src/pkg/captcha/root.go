@@ -0,0 +1,214 @@
+// Package captcha verifies captcha responses with the siteverify API of the
+// captcha provider. reCAPTCHA (v2 and v3), hCaptcha and Turnstile share the same
+// protocol, they only differ in endpoint and the name of the response field.
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Provider struct {
+	Name     string
+	Endpoint string
+	// Form field the widget puts the response in
+	ResponseField string
+	// Script and class of the widget on the form
+	Script      string
+	WidgetClass string
+}
+
+var (
+	Recaptcha = Provider{
+		Name:          "recaptcha",
+		Endpoint:      "https://www.google.com/recaptcha/api/siteverify",
+		ResponseField: "g-recaptcha-response",
+		Script:        "https://www.google.com/recaptcha/api.js",
+		WidgetClass:   "g-recaptcha",
+	}
+	HCaptcha = Provider{
+		Name:          "hcaptcha",
+		Endpoint:      "https://api.hcaptcha.com/siteverify",
+		ResponseField: "h-captcha-response",
+		Script:        "https://js.hcaptcha.com/1/api.js",
+		WidgetClass:   "h-captcha",
+	}
+	Turnstile = Provider{
+		Name:          "turnstile",
+		Endpoint:      "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+		ResponseField: "cf-turnstile-response",
+		Script:        "https://challenges.cloudflare.com/turnstile/v0/api.js",
+		WidgetClass:   "cf-turnstile",
+	}
+)
+
+var Providers = map[string]Provider{
+	Recaptcha.Name:           Recaptcha,
+	HCaptcha.Name:            HCaptcha,
+	Turnstile.Name:           Turnstile,
+	ProofOfWorkProvider.Name: ProofOfWorkProvider,
+}
+
+var (
+	ErrNoResponse = errors.New("Invalid captcha or no captcha provided.")
+	ErrLowScore   = errors.New("Captcha score too low.")
+	ErrNoScore    = errors.New("Captcha has no score, a minimum score needs reCAPTCHA v3.")
+	ErrAction     = errors.New("Captcha was solved for another action.")
+	ErrHostname   = errors.New("Captcha was solved on another website.")
+)
+
+// Answer of the siteverify API, Score and Action are only set by reCAPTCHA v3.
+type Result struct {
+	Success     bool      `json:"success"`
+	Score       *float64  `json:"score"`
+	Action      string    `json:"action"`
+	ChallengeTS time.Time `json:"challenge_ts"`
+	Hostname    string    `json:"hostname"`
+	ErrorCodes  []string  `json:"error-codes"`
+}
+
+type Verifier interface {
+	// Name of the form field that holds the captcha response
+	ResponseField() string
+	// Returns an error when the response is not valid
+	Verify(ctx context.Context, remoteIP string, response string) (Result, error)
+}
+
+type Option func(*SiteVerifier)
+
+// Sends the verification requests to another endpoint, eg. a local stand-in in tests.
+func WithEndpoint(endpoint string) Option {
+	return func(v *SiteVerifier) {
+		if endpoint != "" {
+			v.endpoint = endpoint
+		}
+	}
+}
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(v *SiteVerifier) {
+		v.client = client
+	}
+}
+
+// Rejects responses that scored lower, only applies to providers that score.
+func WithMinScore(score float64) Option {
+	return func(v *SiteVerifier) {
+		v.minScore = score
+	}
+}
+
+// Rejects responses that were solved for another action.
+func WithAction(action string) Option {
+	return func(v *SiteVerifier) {
+		v.action = action
+	}
+}
+
+// Rejects responses that were solved on other hostnames.
+func WithHostnames(hostnames ...string) Option {
+	return func(v *SiteVerifier) {
+		v.hostnames = hostnames
+	}
+}
+
+type SiteVerifier struct {
+	provider  Provider
+	secret    string
+	endpoint  string
+	client    *http.Client
+	minScore  float64
+	action    string
+	hostnames []string
+}
+
+func New(provider Provider, secret string, opts ...Option) *SiteVerifier {
+	v := &SiteVerifier{
+		provider: provider,
+		secret:   secret,
+		endpoint: provider.Endpoint,
+		client:   http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+func NewRecaptcha(secret string, opts ...Option) *SiteVerifier {
+	return New(Recaptcha, secret, opts...)
+}
+
+func NewHCaptcha(secret string, opts ...Option) *SiteVerifier {
+	return New(HCaptcha, secret, opts...)
+}
+
+func NewTurnstile(secret string, opts ...Option) *SiteVerifier {
+	return New(Turnstile, secret, opts...)
+}
+
+func (v *SiteVerifier) ResponseField() string {
+	return v.provider.ResponseField
+}
+
+func (v *SiteVerifier) Verify(ctx context.Context, remoteIP string, response string) (Result, error) {
+	var result Result
+
+	form := url.Values{"secret": {v.secret}, "response": {response}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", v.provider.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return result, fmt.Errorf("%s: invalid response: %w", v.provider.Name, err)
+	}
+
+	if !result.Success {
+		if len(result.ErrorCodes) != 0 {
+			return result, fmt.Errorf("%w (%s)", ErrNoResponse, strings.Join(result.ErrorCodes, ", "))
+		}
+		return result, ErrNoResponse
+	}
+
+	return result, v.check(result)
+}
+
+func (v *SiteVerifier) check(result Result) error {
+	// A v2 key or another provider answers without a score, which must not
+	// slip past a minimum score.
+	if v.minScore > 0 && result.Score == nil {
+		return ErrNoScore
+	}
+	if v.minScore > 0 && *result.Score < v.minScore {
+		return fmt.Errorf("%w (%.1f)", ErrLowScore, *result.Score)
+	}
+
+	if v.action != "" && result.Action != v.action {
+		return ErrAction
+	}
+
+	if len(v.hostnames) != 0 && !slices.Contains(v.hostnames, result.Hostname) {
+		return ErrHostname
+	}
+
+	return nil
+}
@@ -8,16 +8,72 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Ciphertexts are formatted as "gf1:<key id>:<base64 nonce and sealed data>" so
+// they can be decrypted after the key was rotated. Ciphertexts from before key
+// rotation are plain base64 and decrypted with the legacy key derivation.
+//
+// The PBKDF2 salt is fixed on purpose, it is kdfSaltContext followed by the key
+// ID. A salt protects low entropy passwords against precomputed tables, the
+// secrets are random keys that are never shared between deployments, so a
+// random salt would add nothing but a value to store next to every key. The
+// key ID in the salt still gives every key of a deployment its own derivation.
+const (
+	prefix         = "gf1:"
+	kdfIterations  = 100_000
+	kdfSaltContext = "goforms encryption key "
 )
 
+var ErrUnknownKey = errors.New("ciphertext was encrypted with an unknown key")
+
+// A secret with the ID that is stored next to everything it encrypts.
+type Key struct {
+	ID     string
+	Secret string
+}
+
 type Encryptor struct {
-	gcm cipher.AEAD
+	primaryID string
+	keys      map[string]cipher.AEAD
+	// Keys derived the way they were before rotation, for ciphertexts without a key ID
+	legacy []cipher.AEAD
 }
 
-func NewEncryptor(key string) (*Encryptor, error) {
-	keyHash := sha256.Sum256([]byte(key))
+// Encrypts with the primary key, old keys are only used to decrypt.
+func NewEncryptor(primary Key, old ...Key) (*Encryptor, error) {
+	e := &Encryptor{primaryID: primary.ID, keys: map[string]cipher.AEAD{}}
 
-	block, err := aes.NewCipher(keyHash[:])
+	for _, key := range append([]Key{primary}, old...) {
+		if key.ID == "" || strings.Contains(key.ID, ":") {
+			return nil, fmt.Errorf("invalid key id %q", key.ID)
+		}
+		if _, ok := e.keys[key.ID]; ok {
+			return nil, fmt.Errorf("key id %q used more than once", key.ID)
+		}
+
+		gcm, err := newGCM(pbkdf2.Key([]byte(key.Secret), []byte(kdfSaltContext+key.ID), kdfIterations, 32, sha256.New))
+		if err != nil {
+			return nil, err
+		}
+		e.keys[key.ID] = gcm
+
+		legacyHash := sha256.Sum256([]byte(key.Secret))
+		legacy, err := newGCM(legacyHash[:])
+		if err != nil {
+			return nil, err
+		}
+		e.legacy = append(e.legacy, legacy)
+	}
+
+	return e, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cipher: %w", err)
 	}
@@ -27,7 +83,7 @@ func NewEncryptor(key string) (*Encryptor, error) {
 		return nil, fmt.Errorf("failed to create GCM: %w", err)
 	}
 
-	return &Encryptor{gcm: gcm}, nil
+	return gcm, nil
 }
 
 func (e *Encryptor) Encrypt(plaintext string) (string, error) {
@@ -35,13 +91,14 @@ func (e *Encryptor) Encrypt(plaintext string) (string, error) {
 		return "", nil
 	}
 
-	nonce := make([]byte, e.gcm.NonceSize())
+	gcm := e.keys[e.primaryID]
+	nonce := make([]byte, gcm.NonceSize())
 	if _, err := rand.Read(nonce); err != nil {
 		return "", fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
-	ciphertext := e.gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + e.primaryID + ":" + base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
 func (e *Encryptor) Decrypt(ciphertext string) (string, error) {
@@ -49,18 +106,48 @@ func (e *Encryptor) Decrypt(ciphertext string) (string, error) {
 		return "", nil
 	}
 
-	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	// Base64 has no colons, so a ciphertext without the prefix is a legacy one
+	if !strings.HasPrefix(ciphertext, prefix) {
+		return e.decryptLegacy(ciphertext)
+	}
+
+	keyID, encoded, found := strings.Cut(strings.TrimPrefix(ciphertext, prefix), ":")
+	if !found {
+		return "", errors.New("ciphertext has no key id")
+	}
+
+	gcm, ok := e.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownKey, keyID)
+	}
+
+	return open(gcm, encoded)
+}
+
+func (e *Encryptor) decryptLegacy(ciphertext string) (string, error) {
+	var err error
+	for _, gcm := range e.legacy {
+		var plaintext string
+		if plaintext, err = open(gcm, ciphertext); err == nil {
+			return plaintext, nil
+		}
+	}
+	return "", err
+}
+
+func open(gcm cipher.AEAD, encoded string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode base64: %w", err)
 	}
 
-	nonceSize := e.gcm.NonceSize()
+	nonceSize := gcm.NonceSize()
 	if len(data) < nonceSize {
 		return "", errors.New("ciphertext too short")
 	}
 
 	nonce, cipherData := data[:nonceSize], data[nonceSize:]
-	plaintext, err := e.gcm.Open(nil, nonce, cipherData, nil)
+	plaintext, err := gcm.Open(nil, nonce, cipherData, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to decrypt: %w", err)
 	}
@@ -68,6 +155,30 @@ func (e *Encryptor) Decrypt(ciphertext string) (string, error) {
 	return string(plaintext), nil
 }
 
+// Whether the ciphertext is not encrypted with the primary key yet.
+func (e *Encryptor) NeedsRotation(ciphertext string) bool {
+	return ciphertext != "" && !strings.HasPrefix(ciphertext, prefix+e.primaryID+":")
+}
+
+// Re-encrypts the ciphertext with the primary key when it was encrypted with
+// another key, returns whether it changed.
+func (e *Encryptor) Rotate(ciphertext string) (string, bool, error) {
+	if !e.NeedsRotation(ciphertext) {
+		return ciphertext, false, nil
+	}
+
+	plaintext, err := e.Decrypt(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+
+	rotated, err := e.Encrypt(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+	return rotated, true, nil
+}
+
 func (e *Encryptor) EncryptStringSlice(data []string) ([]string, error) {
 	encrypted := make([]string, len(data))
 	for i, item := range data {
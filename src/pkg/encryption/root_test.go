@@ -0,0 +1,143 @@
+package encryption_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/sevaho/goforms/src/pkg/encryption"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Encrypts like before key rotation, with the SHA-256 of the secret as key and
+// without a key ID.
+func legacyEncrypt(secret string, plaintext string) string {
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	Expect(err).To(BeNil())
+	gcm, err := cipher.NewGCM(block)
+	Expect(err).To(BeNil())
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	Expect(err).To(BeNil())
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+var _ = Describe("Encryptor", func() {
+	oldKey := encryption.Key{ID: "1", Secret: "old-secret"}
+	newKey := encryption.Key{ID: "2", Secret: "new-secret"}
+
+	When("The key was rotated", func() {
+		It("should encrypt with the new key and still decrypt with the old one", func() {
+			// given
+			before, err := encryption.NewEncryptor(oldKey)
+			Expect(err).To(BeNil())
+			after, err := encryption.NewEncryptor(newKey, oldKey)
+			Expect(err).To(BeNil())
+			old, err := before.Encrypt("john@example.com")
+			Expect(err).To(BeNil())
+
+			// when
+			encrypted, err := after.Encrypt("jane@example.com")
+			Expect(err).To(BeNil())
+			decryptedOld, errOld := after.Decrypt(old)
+			decryptedNew, errNew := after.Decrypt(encrypted)
+
+			// then
+			Expect(old).To(HavePrefix("gf1:1:"))
+			Expect(encrypted).To(HavePrefix("gf1:2:"))
+			Expect(errOld).To(BeNil())
+			Expect(decryptedOld).To(Equal("john@example.com"))
+			Expect(errNew).To(BeNil())
+			Expect(decryptedNew).To(Equal("jane@example.com"))
+			Expect(after.NeedsRotation(old)).To(BeTrue())
+			Expect(after.NeedsRotation(encrypted)).To(BeFalse())
+		})
+
+		It("should re-encrypt a ciphertext of the old key with the new one", func() {
+			// given
+			before, err := encryption.NewEncryptor(oldKey)
+			Expect(err).To(BeNil())
+			after, err := encryption.NewEncryptor(newKey, oldKey)
+			Expect(err).To(BeNil())
+			old, err := before.Encrypt("Hello")
+			Expect(err).To(BeNil())
+
+			// when
+			rotated, changed, err := after.Rotate(old)
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(changed).To(BeTrue())
+			Expect(rotated).To(HavePrefix("gf1:2:"))
+			decrypted, err := after.Decrypt(rotated)
+			Expect(err).To(BeNil())
+			Expect(decrypted).To(Equal("Hello"))
+		})
+	})
+
+	When("Decrypting a ciphertext from before key rotation", func() {
+		It("should decrypt it with the legacy key of any key", func() {
+			// given
+			encryptor, err := encryption.NewEncryptor(newKey, oldKey)
+			Expect(err).To(BeNil())
+			legacy := legacyEncrypt(oldKey.Secret, "Legacy")
+
+			// when
+			decrypted, err := encryptor.Decrypt(legacy)
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(decrypted).To(Equal("Legacy"))
+			Expect(encryptor.NeedsRotation(legacy)).To(BeTrue())
+		})
+
+		It("should fail when none of the keys encrypted it", func() {
+			// given
+			encryptor, err := encryption.NewEncryptor(newKey)
+			Expect(err).To(BeNil())
+
+			// when
+			_, err = encryptor.Decrypt(legacyEncrypt("other-secret", "Legacy"))
+
+			// then
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	When("Decrypting a ciphertext of an unknown key", func() {
+		It("should return ErrUnknownKey", func() {
+			// given
+			before, err := encryption.NewEncryptor(oldKey)
+			Expect(err).To(BeNil())
+			after, err := encryption.NewEncryptor(newKey)
+			Expect(err).To(BeNil())
+			old, err := before.Encrypt("Hello")
+			Expect(err).To(BeNil())
+
+			// when
+			_, err = after.Decrypt(old)
+
+			// then
+			Expect(err).To(MatchError(encryption.ErrUnknownKey))
+		})
+	})
+
+	When("Creating an encryptor", func() {
+		It("should refuse invalid or duplicate key ids", func() {
+			_, err := encryption.NewEncryptor(encryption.Key{ID: "", Secret: "secret"})
+			Expect(err).NotTo(BeNil())
+
+			_, err = encryption.NewEncryptor(encryption.Key{ID: "a:b", Secret: "secret"})
+			Expect(err).NotTo(BeNil())
+
+			_, err = encryption.NewEncryptor(oldKey, encryption.Key{ID: "1", Secret: "other"})
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
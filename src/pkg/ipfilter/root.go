@@ -0,0 +1,64 @@
+package ipfilter
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// ParsePrefix parses a single IP address or a CIDR range, a single IP address
+// becomes a range that only contains that address.
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Contains reports whether ip falls within the IP address or CIDR range,
+// invalid input never matches.
+func Contains(cidr string, ip string) bool {
+	prefix, err := ParsePrefix(cidr)
+	if err != nil {
+		return false
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	return prefix.Contains(addr.Unmap())
+}
+
+// Truncate zeroes the host part of an IP address, IPv4 addresses keep their /24
+// network and IPv6 addresses their /48 network. Invalid input returns "".
+func Truncate(ip string) string {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.Addr().String()
+}
@@ -1,6 +1,7 @@
 package renderer
 
 import (
+	"bytes"
 	"embed"
 	"html/template"
 	"io"
@@ -16,6 +17,8 @@ import (
 
 type RenderEngine struct { // We need to wrap the renderer because we need a different signature for echo.
 	rnd *render.Render
+	// Renders without the layout, render can not unset the layout per call
+	partial *render.Render
 }
 
 func (r *RenderEngine) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
@@ -33,6 +36,18 @@ func (r *RenderEngine) MustRenderHTML(name string, data map[string]any, layout .
 	return buf.Bytes()
 }
 
+// Renders a template without the layout, eg. snippets embedded in other websites.
+func (r *RenderEngine) RenderPartial(name string, data map[string]any) ([]byte, error) {
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	if err := r.partial.HTML(buf, 0, name, data); err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(buf.Bytes()), nil
+}
+
 func NewRenderEngine(
 	isDevelopment bool,
 	directory string,
@@ -69,7 +84,11 @@ func NewRenderEngine(
 		}
 	}
 
+	partialOptions := options
+	partialOptions.Layout = ""
+
 	return &RenderEngine{
 		render.New(options),
+		render.New(partialOptions),
 	}
 }
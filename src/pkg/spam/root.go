@@ -0,0 +1,226 @@
+// Package spam scores form submissions against a set of configurable content
+// rules, every rule that triggers adds its score to the total. A submission is
+// spam when the total reaches the threshold.
+package spam
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	RuleKeywords  = "keywords"  // Values are case insensitive words or phrases, every match scores
+	RuleRegex     = "regex"     // Values are regular expressions, every match scores
+	RuleMaxLinks  = "maxlinks"  // Scores when there are more than Max links
+	RuleTLD       = "tld"       // Values are top level domains that are not allowed in links, eg. "ru"
+	RuleScript    = "script"    // Values are the allowed unicode scripts, eg. "Latin"
+	RuleMaxLength = "maxlength" // Scores when all fields together are longer than Max characters
+
+	DefaultThreshold = 1
+)
+
+var linkRegex = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"']+`)
+
+type Rule struct {
+	Type   string   `json:"type"`
+	Values []string `json:"values"`
+	Max    int      `json:"max"`
+	Score  float64  `json:"score"`
+}
+
+type Config struct {
+	Threshold float64 `json:"threshold"`
+	Rules     []Rule  `json:"rules"`
+}
+
+type Result struct {
+	Score   float64
+	Spam    bool
+	Reasons []string
+}
+
+type rule struct {
+	Rule
+	patterns []*regexp.Regexp
+	scripts  []*unicode.RangeTable
+}
+
+type Engine struct {
+	threshold float64
+	rules     []rule
+}
+
+// New combines the configs into one engine, rules are added up and the last
+// config with a threshold decides the threshold. Returns an error when a rule
+// is invalid so a bad config is caught at startup.
+func New(configs ...*Config) (*Engine, error) {
+	e := Engine{threshold: DefaultThreshold}
+
+	for _, config := range configs {
+		if config == nil {
+			continue
+		}
+
+		if config.Threshold > 0 {
+			e.threshold = config.Threshold
+		}
+
+		for _, r := range config.Rules {
+			compiled, err := compile(r)
+			if err != nil {
+				return nil, err
+			}
+			e.rules = append(e.rules, compiled)
+		}
+	}
+
+	return &e, nil
+}
+
+func compile(r Rule) (rule, error) {
+	compiled := rule{Rule: r}
+	if compiled.Score == 0 {
+		compiled.Score = 1
+	}
+
+	switch r.Type {
+	case RuleKeywords, RuleTLD:
+		compiled.Values = make([]string, len(r.Values))
+		for i, v := range r.Values {
+			compiled.Values[i] = strings.TrimPrefix(strings.ToLower(v), ".")
+		}
+	case RuleRegex:
+		for _, v := range r.Values {
+			pattern, err := regexp.Compile(v)
+			if err != nil {
+				return rule{}, fmt.Errorf("invalid spam regex %q: %w", v, err)
+			}
+			compiled.patterns = append(compiled.patterns, pattern)
+		}
+	case RuleScript:
+		for _, v := range r.Values {
+			script, ok := unicode.Scripts[v]
+			if !ok {
+				return rule{}, fmt.Errorf("unknown unicode script %q", v)
+			}
+			compiled.scripts = append(compiled.scripts, script)
+		}
+	case RuleMaxLinks, RuleMaxLength:
+	default:
+		return rule{}, fmt.Errorf("unknown spam rule type %q", r.Type)
+	}
+
+	return compiled, nil
+}
+
+// Check scores all values of the submitted fields.
+func (e *Engine) Check(fields url.Values) Result {
+	var values []string
+	for _, v := range fields {
+		values = append(values, v...)
+	}
+	text := strings.Join(values, "\n")
+
+	result := Result{}
+	for _, r := range e.rules {
+		for _, reason := range r.check(text) {
+			result.Score += r.Score
+			result.Reasons = append(result.Reasons, reason)
+		}
+	}
+
+	result.Spam = result.Score >= e.threshold
+	return result
+}
+
+// Flag marks the result as spam for a reason outside of the rules, eg. the
+// classifier.
+func (r *Result) Flag(reason string) {
+	r.Spam = true
+	r.Reasons = append(r.Reasons, reason)
+}
+
+// Returns a reason for every time the rule triggered.
+func (r rule) check(text string) []string {
+	var reasons []string
+
+	switch r.Type {
+	case RuleKeywords:
+		lower := strings.ToLower(text)
+		for _, keyword := range r.Values {
+			if keyword != "" && strings.Contains(lower, keyword) {
+				reasons = append(reasons, "keyword: "+keyword)
+			}
+		}
+	case RuleRegex:
+		for _, pattern := range r.patterns {
+			if pattern.MatchString(text) {
+				reasons = append(reasons, "regex: "+pattern.String())
+			}
+		}
+	case RuleMaxLinks:
+		if links := len(linkRegex.FindAllString(text, -1)); links > r.Max {
+			reasons = append(reasons, fmt.Sprintf("links: %d > %d", links, r.Max))
+		}
+	case RuleTLD:
+		for _, link := range linkRegex.FindAllString(text, -1) {
+			if tld := topLevelDomain(link); tld != "" && contains(r.Values, tld) {
+				reasons = append(reasons, "tld: ."+tld)
+				break
+			}
+		}
+	case RuleScript:
+		for _, c := range text {
+			if !unicode.IsLetter(c) || unicode.In(c, r.scripts...) {
+				continue
+			}
+			reasons = append(reasons, "script: "+scriptOf(c))
+			break
+		}
+	case RuleMaxLength:
+		if length := utf8.RuneCountInString(text); length > r.Max {
+			reasons = append(reasons, fmt.Sprintf("length: %d > %d", length, r.Max))
+		}
+	}
+
+	return reasons
+}
+
+func topLevelDomain(link string) string {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if i := strings.LastIndex(host, "."); i >= 0 {
+		return host[i+1:]
+	}
+	return ""
+}
+
+func scriptOf(c rune) string {
+	for name, table := range unicode.Scripts {
+		if unicode.Is(table, c) {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
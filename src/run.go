@@ -38,3 +38,21 @@ func Run(ctx context.Context, port int, config *config.Config) error {
 	wg.Wait()
 	return nil
 }
+
+func RotateKeys(ctx context.Context, batchSize int, config *config.Config) error {
+	logger.Init(config.IS_DEVELOPMENT, config.LOG_LEVEL)
+
+	return app.RotateKeys(ctx, batchSize, config)
+}
+
+func Reindex(ctx context.Context, batchSize int, config *config.Config) error {
+	logger.Init(config.IS_DEVELOPMENT, config.LOG_LEVEL)
+
+	return app.Reindex(ctx, batchSize, config)
+}
+
+func Purge(ctx context.Context, dryRun bool, config *config.Config) error {
+	logger.Init(config.IS_DEVELOPMENT, config.LOG_LEVEL)
+
+	return app.Purge(ctx, dryRun, config)
+}
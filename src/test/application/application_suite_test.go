@@ -2,21 +2,68 @@ package application_test
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"io"
+	"math/bits"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
 	"testing"
 	"time"
 
+	"github.com/amacneil/dbmate/v2/pkg/dbmate"
 	"github.com/jarcoal/httpmock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/sevaho/goforms/src/assets"
 )
 
+// Runs against the in-memory store with dummy keys unless the environment
+// says otherwise, eg. DB_DSN to test on Postgres or DB_DSN=sqlite: to test on
+// SQLite.
+var defaultEnv = map[string]string{
+	"DB_DSN":                      "memory://",
+	"SECRET_KEY":                  "test-secret",
+	"API_KEY":                     "changeme",
+	"TELEGRAM_BOT_API_KEY":        "test",
+	"TELEGRAM_BOT_CHAT_ID":        "1",
+	"MAILERSEND_API_KEY":          "test",
+	"GOOGLE_RECAPTCHA_SECRET_KEY": "test",
+	// The specs play the reverse proxy
+	"TRUSTED_PROXIES": "127.0.0.1/32,::1/128",
+}
+
 func TestApplication(t *testing.T) {
+	for key, value := range defaultEnv {
+		if _, ok := os.LookupEnv(key); !ok {
+			t.Setenv(key, value)
+		}
+	}
+
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Application Suite")
 }
 
+// Every spec gets an empty SQLite database in a temporary directory, like it
+// gets an empty in-memory store.
+func newSQLiteDatabase() string {
+	dsn := "sqlite:" + filepath.Join(GinkgoT().TempDir(), "goforms.sqlite3")
+	u, err := url.Parse(dsn)
+	Expect(err).To(BeNil())
+
+	db := dbmate.New(u)
+	db.FS = assets.Migrations
+	db.MigrationsDir = []string{"migrations/sqlite"}
+	db.AutoDumpSchema = false
+	db.Log = io.Discard
+	Expect(db.CreateAndMigrate()).To(Succeed())
+
+	return dsn
+}
+
 // Mock Mailersend Email API
 func mockMailersend(captures *[]http.Request) {
 	httpmock.RegisterResponder(
@@ -31,6 +78,16 @@ func mockMailersend(captures *[]http.Request) {
 	)
 }
 
+// Telegram stand-in for the notifications about failing mails, the bot answers
+// getMe and sendMessage with the same object.
+func mockTelegram() {
+	httpmock.RegisterRegexpResponder(
+		"POST",
+		regexp.MustCompile(`^https://api\.telegram\.org/`),
+		httpmock.NewStringResponder(200, `{"ok": true, "result": {"id": 1, "is_bot": true, "first_name": "goforms", "username": "goforms_bot", "message_id": 1, "date": 0, "chat": {"id": 1, "type": "private"}}}`),
+	)
+}
+
 func mockGoogleRecaptcha(captures *[]http.Request) {
 	httpmock.RegisterResponder(
 		"POST",
@@ -44,6 +101,49 @@ func mockGoogleRecaptcha(captures *[]http.Request) {
 	)
 }
 
+func mockGoogleRecaptchaV3(score float64, action string, hostname string) {
+	httpmock.RegisterResponder(
+		"POST",
+		"https://www.google.com/recaptcha/api/siteverify",
+		httpmock.NewStringResponder(200, fmt.Sprintf(`{"success": true, "score": %g, "action": %q, "hostname": %q}`, score, action, hostname)),
+	)
+}
+
+// Turnstile stand-in that only accepts the response "valid-token".
+func mockTurnstile(captures *[]http.Request) {
+	httpmock.RegisterResponder(
+		"POST",
+		"https://challenges.cloudflare.com/turnstile/v0/siteverify",
+		func(req *http.Request) (*http.Response, error) {
+			if captures != nil {
+				*captures = append(*captures, *req)
+			}
+			if err := req.ParseForm(); err != nil || req.PostForm.Get("response") != "valid-token" || req.PostForm.Get("secret") != "turnstile-secret" {
+				return httpmock.NewStringResponse(200, `{"success": false, "error-codes": ["invalid-input-response"]}`), nil
+			}
+			return httpmock.NewStringResponse(200, `{"success": true}`), nil
+		},
+	)
+}
+
+// Brute forces the proof-of-work challenge like the JS solver does.
+func solveProofOfWork(challenge string, difficulty int) string {
+	for counter := 0; ; counter++ {
+		response := fmt.Sprintf("%s:%d", challenge, counter)
+		hash := sha256.Sum256([]byte(response))
+		zeros := 0
+		for _, b := range hash {
+			zeros += bits.LeadingZeros8(b)
+			if b != 0 {
+				break
+			}
+		}
+		if zeros >= difficulty {
+			return response
+		}
+	}
+}
+
 func waitForReady(
 	ctx context.Context,
 	timeout time.Duration,
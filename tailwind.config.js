@@ -1,6 +1,6 @@
 /** @type {import('tailwindcss').Config} */
 module.exports = {
-  content: ["./src/web/templates/**/*.{html,js}", "./src/web/templates/*.{html,js}"],
+  content: ["./src/assets/templates/**/*.{html,js}", "./src/assets/static/js/*.js"],
   theme: {
     extend: {},
   },